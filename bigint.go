@@ -0,0 +1,47 @@
+package stringbank
+
+import (
+	"math/big"
+	"math/bits"
+)
+
+const (
+	bigIntPositive = 0
+	bigIntNegative = 1
+)
+
+// SaveBigInt stores n in the Stringbank as a sign byte followed by the big-endian bytes of its absolute value,
+// which is much more compact than its decimal form. The bytes are written directly into the bank, so nothing is
+// allocated. It returns the index of the number, which can be converted back into a big.Int with GetBigInt
+func (s *Stringbank) SaveBigInt(n *big.Int) int {
+	l := (n.BitLen() + 7) / 8
+	offset, buf := s.alloc(1 + l)
+	buf[0] = bigIntPositive
+	if n.Sign() < 0 {
+		buf[0] = bigIntNegative
+	}
+	// Write the words of the absolute value from the least significant end of the buffer
+	i := len(buf)
+	for _, w := range n.Bits() {
+		for j := 0; j < bits.UintSize/8 && i > 1; j++ {
+			i--
+			buf[i] = byte(w)
+			w >>= 8
+		}
+	}
+	return s.logged(offset)
+}
+
+// GetBigInt returns the number saved by SaveBigInt at index. The result is newly allocated, so may be modified
+// freely
+func (s *Stringbank) GetBigInt(index int) *big.Int {
+	b := s.GetBytes(index)
+	if len(b) == 0 {
+		return nil
+	}
+	n := new(big.Int).SetBytes(b[1:])
+	if b[0] == bigIntNegative {
+		n.Neg(n)
+	}
+	return n
+}
@@ -0,0 +1,40 @@
+package stringbank
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveBigInt(t *testing.T) {
+	huge, ok := new(big.Int).SetString("123456789012345678901234567890123456789012345678901234567890", 10)
+	assert.True(t, ok)
+	tests := []*big.Int{
+		big.NewInt(0),
+		big.NewInt(1),
+		big.NewInt(-1),
+		big.NewInt(255),
+		big.NewInt(256),
+		big.NewInt(-65536),
+		big.NewInt(1<<63 - 1),
+		new(big.Int).Lsh(big.NewInt(1), 64),
+		huge,
+		new(big.Int).Neg(huge),
+	}
+
+	sb := Stringbank{}
+	var indices []int
+	for _, n := range tests {
+		indices = append(indices, sb.SaveBigInt(n))
+	}
+	for i, n := range tests {
+		assert.Equal(t, 0, n.Cmp(sb.GetBigInt(indices[i])), n.String())
+	}
+
+	// The encoding is a sign byte followed by the minimal bytes of the magnitude
+	assert.Equal(t, []byte{0}, sb.GetBytes(indices[0]))
+	assert.Equal(t, []byte{1, 1}, sb.GetBytes(indices[2]))
+	assert.Equal(t, []byte{0, 1, 0}, sb.GetBytes(indices[4]))
+	assert.Len(t, sb.GetBytes(indices[8]), 1+len(huge.Bytes()))
+}
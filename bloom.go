@@ -0,0 +1,58 @@
+package stringbank
+
+// Bloom is a bloom filter built from the contents of a Stringbank. It answers whether a string may be in the bank
+// using far less memory than a set of the strings. False positives are possible, false negatives are not
+type Bloom struct {
+	bits   []uint64
+	nbits  uint64
+	hashes int
+}
+
+// BuildBloom builds a bloom filter of the given number of bits over the strings in the Stringbank. Each string
+// sets hashes bits in the filter
+func (s *Stringbank) BuildBloom(bits int, hashes int) *Bloom {
+	if bits < 1 {
+		bits = 1
+	}
+	if hashes < 1 {
+		hashes = 1
+	}
+	b := &Bloom{
+		bits:   make([]uint64, (bits+63)/64),
+		nbits:  uint64(bits),
+		hashes: hashes,
+	}
+	s.ForEachBytes(func(index int, val []byte) bool {
+		b.add(val)
+		return true
+	})
+	return b
+}
+
+// MayContain returns false if val is definitely not in the Stringbank the filter was built from, and true if it
+// might be
+func (b *Bloom) MayContain(val string) bool {
+	h1, h2 := bloomHashes(stringBytes(val))
+	for i := 0; i < b.hashes; i++ {
+		bit := (h1 + uint64(i)*h2) % b.nbits
+		if b.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *Bloom) add(val []byte) {
+	h1, h2 := bloomHashes(val)
+	for i := 0; i < b.hashes; i++ {
+		bit := (h1 + uint64(i)*h2) % b.nbits
+		b.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+// bloomHashes derives the two hashes used to generate each of the filter's bit positions from a single 64-bit
+// FNV-1a hash of val
+func bloomHashes(val []byte) (uint64, uint64) {
+	h := fnv1a(val)
+	return h & 0xFFFFFFFF, (h >> 32) | 1
+}
@@ -0,0 +1,37 @@
+package stringbank
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBloom(t *testing.T) {
+	sb := Stringbank{}
+	for i := 0; i < 1000; i++ {
+		sb.Save(strconv.Itoa(i))
+	}
+
+	b := sb.BuildBloom(10000, 7)
+	for i := 0; i < 1000; i++ {
+		assert.True(t, b.MayContain(strconv.Itoa(i)))
+	}
+
+	var falsePositives int
+	for i := 1000; i < 2000; i++ {
+		if b.MayContain(strconv.Itoa(i)) {
+			falsePositives++
+		}
+	}
+	// With these parameters we expect a false positive rate of about 1%
+	assert.True(t, falsePositives < 50, "%d false positives", falsePositives)
+	assert.False(t, b.MayContain("definitely not present"))
+}
+
+func TestBloomEmpty(t *testing.T) {
+	sb := Stringbank{}
+	b := sb.BuildBloom(64, 3)
+	assert.False(t, b.MayContain("hello"))
+	assert.False(t, b.MayContain(""))
+}
@@ -0,0 +1,24 @@
+package stringbank
+
+// SetByID saves val and records it as the string for id, replacing any string previously set for id. ids should
+// be small non-negative integers such as error codes, as lookups use a slice indexed by id. SetByID panics if id is
+// negative
+func (s *Stringbank) SetByID(id int, val string) {
+	if id < 0 {
+		panic("stringbank: negative id")
+	}
+	if id >= len(s.byID) {
+		byID := make([]int, id+1, 2*id+1)
+		copy(byID, s.byID)
+		s.byID = byID
+	}
+	s.byID[id] = s.Save(val) + 1
+}
+
+// GetByID returns the string set for id by SetByID, or an empty string if no string has been set
+func (s *Stringbank) GetByID(id int) string {
+	if id < 0 || id >= len(s.byID) || s.byID[id] == 0 {
+		return ""
+	}
+	return s.Get(s.byID[id] - 1)
+}
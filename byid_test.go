@@ -0,0 +1,36 @@
+package stringbank
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestByID(t *testing.T) {
+	sb := Stringbank{}
+	assert.Equal(t, "", sb.GetByID(0))
+
+	sb.SetByID(3, "three")
+	sb.SetByID(0, "zero")
+	sb.SetByID(100, "hundred")
+	sb.SetByID(5, "")
+
+	assert.Equal(t, "zero", sb.GetByID(0))
+	assert.Equal(t, "", sb.GetByID(1))
+	assert.Equal(t, "three", sb.GetByID(3))
+	assert.Equal(t, "", sb.GetByID(5))
+	assert.Equal(t, "", sb.GetByID(50))
+	assert.Equal(t, "hundred", sb.GetByID(100))
+	assert.Equal(t, "", sb.GetByID(101))
+	assert.Equal(t, "", sb.GetByID(-1))
+
+	sb.SetByID(3, "THREE")
+	assert.Equal(t, "THREE", sb.GetByID(3))
+	assert.Equal(t, "hundred", sb.GetByID(100))
+}
+
+func TestSetByIDNegative(t *testing.T) {
+	sb := Stringbank{}
+	assert.PanicsWithValue(t, "stringbank: negative id", func() { sb.SetByID(-1, "minus one") })
+	assert.Zero(t, sb.Len())
+}
@@ -0,0 +1,107 @@
+package stringbank
+
+// SaveCleanPath saves the shortest path equivalent to p, exactly as path.Clean would return it, and returns its
+// index. The path is cleaned as it is copied into the bank, so the cleaned string is never allocated separately,
+// unless p is too long to fit in a chunk.
+func (s *Stringbank) SaveCleanPath(p string) int {
+	if p == "" {
+		return s.Save(".")
+	}
+	if len(p)+spaceForLength(len(p)) > s.newChunkSize() {
+		// Reserving space for p would give it an allocation of its own, which would stay that size however short
+		// the cleaned path turns out to be. So we clean into a temporary buffer and save only the result
+		buf := make([]byte, len(p))
+		return s.SaveBytes(buf[:cleanPath(buf, p)])
+	}
+	// A cleaned path is never longer than the original, so we reserve space for p then give back what we don't use
+	prevWidth := s.maxPrefixWidth
+	index, buf := s.alloc(len(p))
+	n := cleanPath(buf, p)
+	if n < len(p) {
+		s.shrinkLast(index, len(p), n, prevWidth)
+	}
+	return s.logged(index)
+}
+
+// shrinkLast reduces the length of the string just saved at index from l to n, releasing the bytes no longer
+// needed. prevWidth is the largest prefix width before the string was saved
+func (s *Stringbank) shrinkLast(index, l, n, prevWidth int) {
+	slot, offset := s.locate(index)
+	data := s.allocations[slot]
+	width, newWidth := spaceForLength(l), spaceForLength(n)
+	// The length prefix may get shorter, so move the data to follow it
+	copy(data[offset+newWidth:], data[offset+width:offset+width+n])
+	writeLength(n, data[offset:])
+	data = data[:offset+newWidth+n]
+	s.allocations[slot] = data
+	// Only paths that fit in a chunk are shrunk, so the string is at the end of the current chunk
+	s.current = data
+	s.dataBytes -= l - n
+	s.maxPrefixWidth = prevWidth
+	if newWidth > prevWidth {
+		s.maxPrefixWidth = newWidth
+	}
+}
+
+// cleanPath writes the cleaned form of p to dst and returns its length. It follows path.Clean step by step, with dst
+// taking the place of path.Clean's output buffer. p must not be empty, and dst must be at least as long as p
+func cleanPath(dst []byte, p string) int {
+	rooted := p[0] == '/'
+	n := len(p)
+
+	// r is the next byte to read from p, w the next byte to write to dst, and dotdot the point in dst that ..
+	// must not backtrack past
+	r, w, dotdot := 0, 0, 0
+	if rooted {
+		dst[0] = '/'
+		r, w, dotdot = 1, 1, 1
+	}
+
+	for r < n {
+		switch {
+		case p[r] == '/':
+			// empty path element
+			r++
+		case p[r] == '.' && (r+1 == n || p[r+1] == '/'):
+			// . element
+			r++
+		case p[r] == '.' && p[r+1] == '.' && (r+2 == n || p[r+2] == '/'):
+			// .. element: remove to last /
+			r += 2
+			switch {
+			case w > dotdot:
+				// can backtrack
+				w--
+				for w > dotdot && dst[w] != '/' {
+					w--
+				}
+			case !rooted:
+				// cannot backtrack, but not rooted, so append .. element
+				if w > 0 {
+					dst[w] = '/'
+					w++
+				}
+				dst[w], dst[w+1] = '.', '.'
+				w += 2
+				dotdot = w
+			}
+		default:
+			// real path element. Add slash if needed
+			if rooted && w != 1 || !rooted && w != 0 {
+				dst[w] = '/'
+				w++
+			}
+			for ; r < n && p[r] != '/'; r++ {
+				dst[w] = p[r]
+				w++
+			}
+		}
+	}
+
+	// Turn empty string into "."
+	if w == 0 {
+		dst[0] = '.'
+		w = 1
+	}
+	return w
+}
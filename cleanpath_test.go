@@ -0,0 +1,81 @@
+package stringbank
+
+import (
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveCleanPath(t *testing.T) {
+	tests := []string{
+		"",
+		".",
+		"..",
+		"/",
+		"//",
+		"abc",
+		"abc/",
+		"/abc/def/",
+		"a//b///c",
+		"./a/./b/.",
+		"a/b/../c",
+		"a/b/../../..",
+		"../../a/b",
+		"/../a",
+		"/a/b/c/../../..",
+		"a/../b/./../c//",
+		"abc/def/ghi/../jkl/./mno/..",
+		"/" + strings.Repeat("a/./", 100),
+		strings.Repeat("x/../", 50) + "y",
+	}
+
+	sb := New(1 << 10)
+	plain := New(1 << 10)
+	var indices []int
+	for _, test := range tests {
+		indices = append(indices, sb.SaveCleanPath(test))
+		plain.Save(path.Clean(test))
+	}
+	for i, test := range tests {
+		assert.Equal(t, path.Clean(test), sb.Get(indices[i]), test)
+	}
+
+	// The bank ends up exactly as if the cleaned paths had been saved
+	assert.Equal(t, plain.Used(), sb.Used())
+	assert.Equal(t, plain.DataBytes(), sb.DataBytes())
+	assert.Equal(t, plain.MaxPrefixWidth(), sb.MaxPrefixWidth())
+	assert.Equal(t, plain.Len(), sb.Len())
+	var i int
+	sb.ForEach(func(index int, val string) bool {
+		assert.Equal(t, indices[i], index)
+		i++
+		return true
+	})
+	assert.Equal(t, len(tests), i)
+}
+
+func TestSaveCleanPathLarge(t *testing.T) {
+	sb := New(64)
+	p := strings.Repeat("a/b/../", 30)
+	index := sb.SaveCleanPath(p)
+	assert.Equal(t, path.Clean(p), sb.Get(index))
+	next := sb.Save("next")
+	assert.Equal(t, "next", sb.Get(next))
+	assert.Equal(t, path.Clean(p), sb.Get(index))
+}
+
+func TestSaveCleanPathLargeShrinks(t *testing.T) {
+	sb := Stringbank{}
+	// Far longer than a chunk, but cleans to a single byte
+	index := sb.SaveCleanPath("/" + strings.Repeat("./", 150000))
+	assert.Equal(t, "/", sb.Get(index))
+	assert.Equal(t, stringbankSize, sb.Size())
+
+	// A long path that stays long still gets an allocation of its own
+	p := "/" + strings.Repeat("abc/./", 100000)
+	index = sb.SaveCleanPath(p)
+	assert.Equal(t, path.Clean(p), sb.Get(index))
+	assert.Equal(t, stringbankSize+len(path.Clean(p))+spaceForLength(len(path.Clean(p))), sb.Size())
+}
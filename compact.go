@@ -0,0 +1,51 @@
+package stringbank
+
+// SavePinned copies a string into the Stringbank and pins it, so it is always preserved by CompactKeeping. It
+// returns the index of the string in the bank
+func (s *Stringbank) SavePinned(val string) int {
+	index := s.Save(val)
+	s.Pin(index)
+	return index
+}
+
+// Pin marks the string at index so that it is always preserved by CompactKeeping
+func (s *Stringbank) Pin(index int) {
+	if s.pinned == nil {
+		s.pinned = make(map[int]struct{})
+	}
+	s.pinned[index] = struct{}{}
+}
+
+// Unpin removes the mark set by Pin or SavePinned
+func (s *Stringbank) Unpin(index int) {
+	delete(s.pinned, index)
+}
+
+// IsPinned returns true if the string at index is pinned
+func (s *Stringbank) IsPinned(index int) bool {
+	_, ok := s.pinned[index]
+	return ok
+}
+
+// CompactKeeping returns a new Stringbank containing only the strings for which keep returns true, plus any pinned
+// strings, which remain pinned in the new bank. Strings are copied in their original order. If moved is not nil it
+// is called with the old and new index of each string that is copied. The new bank uses the same chunk size as s.
+func (s *Stringbank) CompactKeeping(keep func(index int, val string) bool, moved func(oldIndex, newIndex int)) *Stringbank {
+	n := &Stringbank{chunk: s.chunk, nextChunk: s.nextChunk}
+	s.ForEachBytes(func(index int, b []byte) bool {
+		val := s.Get(index)
+		pinned := s.IsPinned(index)
+		if !pinned && !keep(index, val) {
+			return true
+		}
+		newIndex := n.SaveBytes(b)
+		if pinned {
+			n.Pin(newIndex)
+		}
+		if moved != nil {
+			moved(index, newIndex)
+		}
+		return true
+	})
+	return n
+}
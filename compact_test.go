@@ -0,0 +1,73 @@
+package stringbank
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompactKeeping(t *testing.T) {
+	sb := Stringbank{}
+	s1 := sb.Save("hello")
+	s2 := sb.Save("goodbye")
+	s3 := sb.Save("cheese")
+
+	moves := map[int]int{}
+	n := sb.CompactKeeping(func(index int, val string) bool {
+		return val != "goodbye"
+	}, func(oldIndex, newIndex int) {
+		moves[oldIndex] = newIndex
+	})
+
+	assert.Len(t, moves, 2)
+	assert.Equal(t, "hello", n.Get(moves[s1]))
+	assert.Equal(t, "cheese", n.Get(moves[s3]))
+	assert.NotContains(t, moves, s2)
+}
+
+func TestCompactKeepingChunkSize(t *testing.T) {
+	sb := New(64)
+	var indices []int
+	for i := 0; i < 100; i++ {
+		indices = append(indices, sb.Save(strconv.Itoa(i)))
+	}
+
+	n := sb.CompactKeeping(func(index int, val string) bool { return true }, nil)
+	assert.Equal(t, 64, n.chunkSize())
+	// Keeping everything in a bank with the same chunk size leaves every string where it was
+	for _, index := range indices {
+		assert.Equal(t, sb.Get(index), n.Get(index))
+	}
+	assert.Equal(t, sb.Size(), n.Size())
+}
+
+func TestCompactKeepingPinned(t *testing.T) {
+	sb := Stringbank{}
+	sb.Save("hello")
+	p1 := sb.SavePinned("goodbye")
+	s3 := sb.Save("cheese")
+	sb.Pin(s3)
+	sb.Unpin(s3)
+
+	assert.True(t, sb.IsPinned(p1))
+	assert.False(t, sb.IsPinned(s3))
+
+	moves := map[int]int{}
+	n := sb.CompactKeeping(func(index int, val string) bool {
+		return false
+	}, func(oldIndex, newIndex int) {
+		moves[oldIndex] = newIndex
+	})
+
+	assert.Equal(t, map[int]int{p1: 0}, moves)
+	assert.Equal(t, "goodbye", n.Get(0))
+	assert.True(t, n.IsPinned(0))
+
+	var count int
+	n.ForEachBytes(func(index int, b []byte) bool {
+		count++
+		return true
+	})
+	assert.Equal(t, 1, count)
+}
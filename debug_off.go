@@ -0,0 +1,7 @@
+//go:build !stringbankdebug
+// +build !stringbankdebug
+
+package stringbank
+
+// debug enables extra checks. Build with the stringbankdebug tag to turn it on
+const debug = false
@@ -0,0 +1,34 @@
+package stringbank
+
+import "sort"
+
+// Dictionary assigns each distinct string a small dense code, as used for dictionary encoding in columnar
+// stores. Codes are assigned in the order strings are first seen, starting from zero. The strings are held in a
+// Stringbank, so a Dictionary gives the GC very little to do.
+type Dictionary struct {
+	bank Stringbank
+	// indices holds the index in bank of the string for each code. As strings are added in order their indices
+	// are increasing, so we can also search this to find the code for an index
+	indices []int
+}
+
+// Encode returns the code for val, assigning the next code if val has not been seen before
+func (d *Dictionary) Encode(val string) uint32 {
+	count := d.bank.Len()
+	index := d.bank.SaveUnique(val)
+	if d.bank.Len() > count {
+		d.indices = append(d.indices, index)
+		return uint32(len(d.indices) - 1)
+	}
+	return uint32(sort.SearchInts(d.indices, index))
+}
+
+// Decode returns the string for code. It panics if code has not been assigned
+func (d *Dictionary) Decode(code uint32) string {
+	return d.bank.Get(d.indices[code])
+}
+
+// Len returns the number of codes assigned
+func (d *Dictionary) Len() int {
+	return len(d.indices)
+}
@@ -0,0 +1,37 @@
+package stringbank
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDictionary(t *testing.T) {
+	column := []string{"red", "green", "red", "blue", "green", "red", "", "blue", ""}
+
+	var d Dictionary
+	var codes []uint32
+	for _, v := range column {
+		codes = append(codes, d.Encode(v))
+	}
+
+	assert.Equal(t, []uint32{0, 1, 0, 2, 1, 0, 3, 2, 3}, codes)
+	assert.Equal(t, 4, d.Len())
+	for i, code := range codes {
+		assert.Equal(t, column[i], d.Decode(code))
+	}
+	assert.Panics(t, func() { d.Decode(4) })
+}
+
+func TestDictionaryMany(t *testing.T) {
+	var d Dictionary
+	for i := 0; i < 100000; i++ {
+		assert.Equal(t, uint32(i), d.Encode(strconv.Itoa(i)))
+	}
+	for i := 99999; i >= 0; i-- {
+		assert.Equal(t, uint32(i), d.Encode(strconv.Itoa(i)))
+		assert.Equal(t, strconv.Itoa(i), d.Decode(uint32(i)))
+	}
+	assert.Equal(t, 100000, d.Len())
+}
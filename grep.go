@@ -0,0 +1,16 @@
+package stringbank
+
+import "regexp"
+
+// Grep returns the indices of the strings in the Stringbank that match re, in the order they were saved. Each
+// string is matched in place, without being copied.
+func (s *Stringbank) Grep(re *regexp.Regexp) []int {
+	var indices []int
+	s.ForEachBytes(func(index int, b []byte) bool {
+		if re.Match(b) {
+			indices = append(indices, index)
+		}
+		return true
+	})
+	return indices
+}
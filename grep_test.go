@@ -0,0 +1,21 @@
+package stringbank
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGrep(t *testing.T) {
+	sb := Stringbank{}
+	sb.Save("GET /index.html 200")
+	s2 := sb.Save("GET /missing 404")
+	sb.Save("POST /form 200")
+	s4 := sb.Save("POST /broken 500")
+	sb.Save("")
+
+	assert.Equal(t, []int{s2, s4}, sb.Grep(regexp.MustCompile(`[45]\d\d$`)))
+	assert.Nil(t, sb.Grep(regexp.MustCompile(`DELETE`)))
+	assert.Len(t, sb.Grep(regexp.MustCompile(``)), 5)
+}
@@ -0,0 +1,74 @@
+package stringbank
+
+// group tracks the strings saved between BeginGroup and EndGroup
+type group struct {
+	active bool
+	// chunk is the chunk holding the first string saved in the group, or -1 if none has been saved yet
+	chunk int
+	split bool
+}
+
+// BeginGroup starts a group of related strings, such as the fields of a record, that should be kept together in
+// memory. Strings saved until EndGroup is called share a chunk, provided the group fits in a chunk. size is the
+// space the group will take: the total length of its strings plus a byte for each length prefix, or more for
+// strings of 128 bytes or longer. If the group won't fit in the space left in the current chunk, BeginGroup starts
+// a new chunk.
+func (s *Stringbank) BeginGroup(size int) {
+	if cap(s.current)-len(s.current) < size {
+		s.current = nil
+	}
+	s.group = group{active: true, chunk: -1}
+}
+
+// EndGroup ends the group started by BeginGroup. It returns false if the group was too large for a chunk, in
+// which case its strings were split across chunks
+func (s *Stringbank) EndGroup() bool {
+	ok := !s.group.split
+	s.group = group{}
+	return ok
+}
+
+// noteGroup records that a string has been saved at index while a group is active
+func (s *Stringbank) noteGroup(index int) {
+	chunk, _ := s.locate(index)
+	if s.group.chunk == -1 {
+		s.group.chunk = chunk
+	} else if chunk != s.group.chunk {
+		s.group.split = true
+	}
+}
+
+// SameChunk returns true if the strings at indices a and b are held in the same chunk
+func (s *Stringbank) SameChunk(a, b int) bool {
+	chunkA, _ := s.locate(a)
+	chunkB, _ := s.locate(b)
+	return chunkA == chunkB
+}
+
+// CurrentChunkStats returns the number of bytes used in the chunk that strings are currently being saved into,
+// and the capacity of that chunk. Both are zero if no chunk is in use, for instance just after a large string has
+// been saved or a group begun that didn't fit. Callers can use this to decide whether to start a new chunk before saving related
+// strings
+func (s *Stringbank) CurrentChunkStats() (used, capacity int) {
+	return len(s.current), cap(s.current)
+}
+
+// Distribution returns the number of strings held in each chunk of the bank, in the order the chunks were
+// allocated. A large string with an allocation of its own counts as a chunk holding one string. This shows how
+// strings have been spread across chunks, for instance by BeginGroup
+func (s *Stringbank) Distribution() []int {
+	var counts []int
+	for _, data := range s.allocations {
+		if data == nil {
+			// This is the index space following a large string or chunk
+			continue
+		}
+		var count int
+		for offset := 0; offset < len(data); count++ {
+			l, llen := readLength(data[offset:])
+			offset += llen + l
+		}
+		counts = append(counts, count)
+	}
+	return counts
+}
@@ -0,0 +1,122 @@
+package stringbank
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroup(t *testing.T) {
+	sb := New(1 << 10)
+	// Nearly fill the first chunk so the group wouldn't fit in what remains
+	first := sb.Save(strings.Repeat("a", 1000))
+
+	sb.BeginGroup(70)
+	var indices []int
+	for i := 0; i < 10; i++ {
+		indices = append(indices, sb.Save("field"+strconv.Itoa(i)))
+	}
+	assert.True(t, sb.EndGroup())
+
+	assert.False(t, sb.SameChunk(first, indices[0]))
+	for _, index := range indices {
+		assert.True(t, sb.SameChunk(indices[0], index))
+	}
+	for i, index := range indices {
+		assert.Equal(t, "field"+strconv.Itoa(i), sb.Get(index))
+	}
+
+	// A group at the start of an empty chunk stays there
+	after := sb.Save("x")
+	assert.True(t, sb.SameChunk(indices[0], after))
+	sb = New(1 << 10)
+	sb.BeginGroup(2)
+	s1 := sb.Save("y")
+	assert.True(t, sb.EndGroup())
+	assert.Equal(t, 0, s1)
+}
+
+func TestGroupFits(t *testing.T) {
+	sb := Stringbank{}
+	for i := 0; i < 10000; i++ {
+		sb.BeginGroup(21)
+		s1 := sb.Save("field1")
+		s2 := sb.Save("field2")
+		s3 := sb.Save("field3")
+		assert.True(t, sb.EndGroup())
+		assert.True(t, sb.SameChunk(s1, s2))
+		assert.True(t, sb.SameChunk(s1, s3))
+	}
+	// Groups that fit in the current chunk don't start a new one, so little space is wasted
+	assert.Equal(t, 210000, sb.Used())
+	assert.True(t, sb.Size() <= sb.Used()+stringbankSize, sb.Size())
+	assert.Len(t, sb.Distribution(), 1)
+}
+
+func TestGroupTooLarge(t *testing.T) {
+	sb := New(1 << 10)
+	sb.BeginGroup(1604)
+	s1 := sb.Save(strings.Repeat("a", 800))
+	s2 := sb.Save(strings.Repeat("b", 800))
+	assert.False(t, sb.EndGroup())
+	assert.False(t, sb.SameChunk(s1, s2))
+
+	// Ending the group resets things
+	sb.BeginGroup(6)
+	sb.Save("hello")
+	assert.True(t, sb.EndGroup())
+}
+
+func TestCurrentChunkStats(t *testing.T) {
+	sb := New(64)
+	used, capacity := sb.CurrentChunkStats()
+	assert.Zero(t, used)
+	assert.Zero(t, capacity)
+
+	sb.Save("hello")
+	sb.Save("")
+	used, capacity = sb.CurrentChunkStats()
+	assert.Equal(t, 7, used)
+	assert.Equal(t, 64, capacity)
+
+	// This doesn't fit in the remaining space so starts a new chunk
+	sb.Save(strings.Repeat("a", 60))
+	used, capacity = sb.CurrentChunkStats()
+	assert.Equal(t, 61, used)
+	assert.Equal(t, 64, capacity)
+	assert.Len(t, sb.allocations, 2)
+
+	sb.Save(strings.Repeat("a", 100))
+	used, capacity = sb.CurrentChunkStats()
+	assert.Zero(t, used)
+	assert.Zero(t, capacity)
+}
+
+func TestDistribution(t *testing.T) {
+	sb := New(64)
+	assert.Empty(t, sb.Distribution())
+
+	for i := 0; i < 20; i++ {
+		sb.Save("abc")
+	}
+	// Each chunk holds 16 strings
+	assert.Equal(t, []int{16, 4}, sb.Distribution())
+
+	// Starting a group that won't fit in the rest of the chunk forces a new chunk
+	sb.BeginGroup(60)
+	sb.Save("abc")
+	sb.Save("abc")
+	assert.True(t, sb.EndGroup())
+	sb.Save(strings.Repeat("a", 100))
+	sb.Save("abc")
+	dist := sb.Distribution()
+	assert.Equal(t, []int{16, 4, 2, 1, 1}, dist)
+
+	var total int
+	for _, count := range dist {
+		total += count
+	}
+	assert.Equal(t, sb.Len(), total)
+}
@@ -0,0 +1,30 @@
+package stringbank
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// fnv1a is the 64-bit FNV-1a hash of val. It is used wherever we need to hash the contents of strings held in the
+// bank, as it needs no allocation
+func fnv1a(val []byte) uint64 {
+	const (
+		offset64 = 14695981039346656037
+		prime64  = 1099511628211
+	)
+	h := uint64(offset64)
+	for _, c := range val {
+		h ^= uint64(c)
+		h *= prime64
+	}
+	return h
+}
+
+// stringBytes returns a byte slice that aliases the data of val. The slice must not be modified
+func stringBytes(val string) (b []byte) {
+	sh := (*reflect.SliceHeader)(unsafe.Pointer(&b))
+	sh.Data = (*reflect.StringHeader)(unsafe.Pointer(&val)).Data
+	sh.Len = len(val)
+	sh.Cap = len(val)
+	return b
+}
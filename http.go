@@ -0,0 +1,32 @@
+package stringbank
+
+import (
+	"encoding/binary"
+	"net/http"
+	"strconv"
+)
+
+// ServeIndex writes the string at index as the body of an HTTP response. Content-Length is set from the length of
+// the string, and the bytes are written straight from the bank without being copied. Any other headers, such as
+// Content-Type, should be set before calling ServeIndex. If index is not within the bank, or what it points at
+// can't be a string, ServeIndex responds with 404 Not Found. The index may come from a URL, so it is checked rather
+// than trusted.
+func (s *Stringbank) ServeIndex(w http.ResponseWriter, index int) {
+	if !s.Owns(index) {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+	// An index within a string decodes part of the string as a length, so check the length fits before slicing
+	slot, offset := s.locate(index)
+	data := s.allocations[slot]
+	l, llen := binary.Uvarint(data[offset:])
+	if llen <= 0 || l > uint64(len(data)-offset-llen) {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+	start := offset + llen
+	b := data[start : start+int(l)]
+	w.Header().Set("Content-Length", strconv.Itoa(len(b)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
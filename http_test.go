@@ -0,0 +1,35 @@
+package stringbank
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServeIndex(t *testing.T) {
+	sb := New(1 << 10)
+	vals := []string{"hello", "", strings.Repeat("large ", 1000)}
+	var indices []int
+	for _, v := range vals {
+		indices = append(indices, sb.Save(v))
+	}
+
+	for i, v := range vals {
+		w := httptest.NewRecorder()
+		sb.ServeIndex(w, indices[i])
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, v, w.Body.String())
+		assert.Equal(t, strconv.Itoa(len(v)), w.Header().Get("Content-Length"))
+	}
+
+	for _, index := range []int{-1, indices[0] + 1, indices[1] + 1, indices[2] + 1<<10, 1 << 20} {
+		w := httptest.NewRecorder()
+		sb.ServeIndex(w, index)
+		assert.Equal(t, http.StatusNotFound, w.Code, index)
+		assert.Equal(t, "Not Found\n", w.Body.String())
+	}
+}
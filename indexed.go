@@ -0,0 +1,56 @@
+package stringbank
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+)
+
+// The indexed format is written by WriteIndexed, and read by OpenIndexed in the offheap package. It suits banks
+// that are only ever read, as any string can be found by ordinal without walking the strings before it. The strings
+// are held one after another without length prefixes, and a table of their offsets precedes them. All integers are
+// little-endian.
+//
+//	magic     "SBIX"
+//	version   1 byte
+//	padding   3 bytes
+//	count     uint64
+//	offsets   (count+1) x uint64
+//	data
+//
+// String i runs from offsets[i] to offsets[i+1], measured from the start of the data.
+const (
+	indexedMagic   = "SBIX"
+	indexedVersion = 1
+)
+
+// WriteIndexed writes the strings in the Stringbank to w in a read-optimized format, in the order they were saved.
+// The result can be opened with OpenIndexed in the offheap package, which maps the file rather than loading it.
+// Unlike WriteTo, indices are not preserved: strings are instead found by their position in the order of saves.
+func (s *Stringbank) WriteIndexed(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	var buf [8]byte
+	bw.WriteString(indexedMagic)
+	bw.Write([]byte{indexedVersion, 0, 0, 0})
+	binary.LittleEndian.PutUint64(buf[:], uint64(s.Len()))
+	bw.Write(buf[:])
+
+	var offset uint64
+	writeOffset := func() {
+		binary.LittleEndian.PutUint64(buf[:], offset)
+		bw.Write(buf[:])
+	}
+	s.ForEachBytes(func(index int, b []byte) bool {
+		writeOffset()
+		offset += uint64(len(b))
+		return true
+	})
+	writeOffset()
+
+	s.ForEachBytes(func(index int, b []byte) bool {
+		bw.Write(b)
+		return true
+	})
+	return bw.Flush()
+}
@@ -0,0 +1,61 @@
+package stringbank
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteIndexed(t *testing.T) {
+	sb := New(64)
+	vals := []string{"hello", "", strings.Repeat("a", 100), "world"}
+	for _, v := range vals {
+		sb.Save(v)
+	}
+	var buf bytes.Buffer
+	require.NoError(t, sb.WriteIndexed(&buf))
+
+	data := buf.Bytes()
+	assert.Equal(t, "SBIX\x01\x00\x00\x00", string(data[:8]))
+	assert.Equal(t, uint64(len(vals)), binary.LittleEndian.Uint64(data[8:]))
+	table := data[16 : 16+8*(len(vals)+1)]
+	strs := data[len(table)+16:]
+	for i, v := range vals {
+		start, end := binary.LittleEndian.Uint64(table[8*i:]), binary.LittleEndian.Uint64(table[8*i+8:])
+		assert.Equal(t, v, string(strs[start:end]))
+	}
+	assert.Equal(t, uint64(len(strs)), binary.LittleEndian.Uint64(table[8*len(vals):]))
+}
+
+// indexedGolden is a file written by WriteIndexed. The offheap package's tests check OpenIndexed can read it
+const indexedGolden = "offheap/testdata/indexed.sbix"
+
+func TestWriteIndexedGolden(t *testing.T) {
+	// The offheap package's tests expect exactly these strings
+	sb := Stringbank{}
+	for i := 0; i < 1000; i++ {
+		v := strconv.Itoa(i)
+		switch i % 100 {
+		case 0:
+			v = strings.Repeat(v, 100)
+		case 1:
+			v = ""
+		}
+		sb.Save(v)
+	}
+	var buf bytes.Buffer
+	require.NoError(t, sb.WriteIndexed(&buf))
+
+	if *update {
+		require.NoError(t, os.WriteFile(indexedGolden, buf.Bytes(), 0644))
+	}
+	golden, err := os.ReadFile(indexedGolden)
+	require.NoError(t, err)
+	assert.True(t, bytes.Equal(golden, buf.Bytes()), "run go test -update to regenerate %s", indexedGolden)
+}
@@ -0,0 +1,254 @@
+package stringbank
+
+import (
+	"runtime"
+	"sync"
+)
+
+// internTable is an open-addressed hash table that maps the contents of strings saved in a Stringbank to their
+// indices. Only indices are stored, and keys are compared against the bytes already held in the bank, so the
+// table contains no pointers for the GC to scan and does not retain the caller's strings
+type internTable struct {
+	// slots holds index+1 of each string in the table, or zero for an empty slot
+	slots []int
+	count int
+	// hits counts the number of times a string was found already present
+	hits int
+}
+
+// find looks for val in the table. If val is present it returns the slot holding it and true. Otherwise it
+// returns the empty slot where val should be inserted and false
+func (t *internTable) find(s *Stringbank, val string, h uint64) (int, bool) {
+	mask := uint64(len(t.slots) - 1)
+	for slot := h & mask; ; slot = (slot + 1) & mask {
+		entry := t.slots[slot]
+		if entry == 0 {
+			return int(slot), false
+		}
+		// Compare the full contents in case of a hash collision
+		if s.Get(entry-1) == val {
+			return int(slot), true
+		}
+	}
+}
+
+// grow doubles the size of the table if it is too full to take another entry
+func (t *internTable) grow(s *Stringbank) {
+	if (t.count+1)*4 <= len(t.slots)*3 {
+		return
+	}
+	size := len(t.slots) * 2
+	if size == 0 {
+		size = 64
+	}
+	old := t.slots
+	t.slots = make([]int, size)
+	mask := uint64(size - 1)
+	for _, entry := range old {
+		if entry == 0 {
+			continue
+		}
+		slot := fnv1a(stringBytes(s.Get(entry-1))) & mask
+		for t.slots[slot] != 0 {
+			slot = (slot + 1) & mask
+		}
+		t.slots[slot] = entry
+	}
+}
+
+// add adds the string already saved at index to the table unless an identical string is already present. It
+// returns true if the string was added
+func (t *internTable) add(s *Stringbank, index int) bool {
+	val := s.Get(index)
+	t.grow(s)
+	slot, found := t.find(s, val, fnv1a(stringBytes(val)))
+	if found {
+		return false
+	}
+	t.slots[slot] = index + 1
+	t.count++
+	return true
+}
+
+// contains returns true if val is in the table
+func (t *internTable) contains(s *Stringbank, val string) bool {
+	if t.count == 0 {
+		return false
+	}
+	_, found := t.find(s, val, fnv1a(stringBytes(val)))
+	return found
+}
+
+// SaveUnique interns a string. It copies the string into the Stringbank unless an identical string has already
+// been saved by SaveUnique, in which case the index of the existing string is returned. Strings saved with Save
+// are not considered, so Save remains available for strings known to be unique.
+//
+// The table used to find existing strings holds only indices into the bank and compares strings against the
+// bytes held in the bank, so interning adds little for the GC to scan and never retains the caller's string.
+func (s *Stringbank) SaveUnique(val string) int {
+	return s.saveUnique(val, fnv1a(stringBytes(val)))
+}
+
+func (s *Stringbank) saveUnique(val string, h uint64) int {
+	s.intern.grow(s)
+	slot, found := s.intern.find(s, val, h)
+	if found {
+		s.intern.hits++
+		return s.intern.slots[slot] - 1
+	}
+	index := s.Save(val)
+	s.intern.slots[slot] = index + 1
+	s.intern.count++
+	return index
+}
+
+// Lookup returns the index of val if it has been saved by SaveUnique, and true. If val has not been interned it
+// returns false, and nothing is saved
+func (s *Stringbank) Lookup(val string) (int, bool) {
+	if s.intern.count == 0 {
+		return 0, false
+	}
+	slot, found := s.intern.find(s, val, fnv1a(stringBytes(val)))
+	if !found {
+		return 0, false
+	}
+	return s.intern.slots[slot] - 1, true
+}
+
+// MergeInterned copies the strings in other into s with SaveUnique semantics, so strings already interned in s
+// are not saved again and every string from other is interned in s afterwards. If moved is not nil it is called
+// for each string in other, in the order they were saved, with the string's index in other and its index in s.
+// Merging does not count towards the hits reported by InternStats
+func (s *Stringbank) MergeInterned(other *Stringbank, moved func(oldIndex, newIndex int)) {
+	hits := s.intern.hits
+	other.ForEachBytes(func(index int, b []byte) bool {
+		newIndex := s.SaveUnique(other.Get(index))
+		if moved != nil {
+			moved(index, newIndex)
+		}
+		return true
+	})
+	s.intern.hits = hits
+}
+
+// InternStats reports the number of unique strings saved by SaveUnique, and the number of calls to SaveUnique that
+// found the string already present and so saved nothing
+func (s *Stringbank) InternStats() (unique, hits int) {
+	return s.intern.count, s.intern.hits
+}
+
+// DistinctCount returns the number of distinct strings in the bank, however they were saved. It builds a
+// temporary table that refers to the strings in place, so nothing is copied and nothing is kept once it returns.
+// Comparing this with Len shows how much interning would have saved
+func (s *Stringbank) DistinctCount() int {
+	var seen internTable
+	s.ForEachBytes(func(index int, b []byte) bool {
+		seen.add(s, index)
+		return true
+	})
+	return seen.count
+}
+
+// SaveLongest keeps the longest value seen for each key. candidate is saved only if it is longer than the value
+// currently held for key, or if key has no value yet. It returns the index of the value now held for key. Keys are
+// saved in the bank with SaveUnique, and the mapping from keys to values holds only indices.
+func (s *Stringbank) SaveLongest(key, candidate string) int {
+	keyIndex := s.SaveUnique(key)
+	if index, ok := s.longest[keyIndex]; ok && len(s.Get(index)) >= len(candidate) {
+		return index
+	}
+	if s.longest == nil {
+		s.longest = make(map[int]int)
+	}
+	index := s.Save(candidate)
+	s.longest[keyIndex] = index
+	return index
+}
+
+// internAllMinPerWorker is the smallest number of strings InternAll will give to each worker. Below this the cost
+// of starting workers and merging their results outweighs any gain
+const internAllMinPerWorker = 4096
+
+// InternAll saves each of vals with SaveUnique semantics and returns their indices. Large inputs are split across
+// several goroutines, each of which deduplicates its share into its own Stringbank. The distinct strings are then
+// merged into s in the order they first appear in vals, so the result is exactly that of calling SaveUnique on
+// each string in turn.
+func (s *Stringbank) InternAll(vals []string) []int {
+	indices := make([]int, len(vals))
+
+	workers := runtime.GOMAXPROCS(0)
+	if limit := len(vals) / internAllMinPerWorker; workers > limit {
+		workers = limit
+	}
+	if workers <= 1 {
+		for i, val := range vals {
+			indices[i] = s.SaveUnique(val)
+		}
+		return indices
+	}
+
+	// Each worker writes the local ordinal of each string's first occurrence in its shard into indices, and
+	// collects its distinct strings in a local bank
+	shards := make([]Stringbank, workers)
+	shardSize := (len(vals) + workers - 1) / workers
+	var wg sync.WaitGroup
+	for w := range shards {
+		start, end := w*shardSize, (w+1)*shardSize
+		if end > len(vals) {
+			end = len(vals)
+		}
+		wg.Add(1)
+		go func(local *Stringbank, vals []string, indices []int) {
+			defer wg.Done()
+			ordinals := map[int]int{}
+			for i, val := range vals {
+				index := local.SaveUnique(val)
+				ordinal, ok := ordinals[index]
+				if !ok {
+					ordinal = len(ordinals)
+					ordinals[index] = ordinal
+				}
+				indices[i] = ordinal
+			}
+		}(&shards[w], vals[start:end], indices[start:end])
+	}
+	wg.Wait()
+
+	// Merge each shard in turn, remapping local ordinals to indices in s
+	for w := range shards {
+		start, end := w*shardSize, (w+1)*shardSize
+		if end > len(vals) {
+			end = len(vals)
+		}
+		remap := make([]int, 0, shards[w].count)
+		shards[w].ForEachBytes(func(index int, b []byte) bool {
+			remap = append(remap, s.SaveUnique(shards[w].Get(index)))
+			return true
+		})
+		for i := start; i < end; i++ {
+			indices[i] = remap[indices[i]]
+		}
+		// Strings repeated within the shard were hits too, though the merge only saw them once
+		s.intern.hits += shards[w].intern.hits
+	}
+
+	return indices
+}
+
+// EstimateDedup reports how much interning would save when storing vals. It returns the number of distinct
+// strings and the total number of strings, along with the bytes the strings would take in a bank if saved with
+// SaveUnique and if saved with Save. The byte counts include length prefixes but not unused space at the end of
+// chunks. No bank is built, so this is a cheap way to decide whether SaveUnique is worth its cost
+func EstimateDedup(vals []string) (distinct int, total int, bytesIfInterned int, bytesIfPlain int) {
+	seen := make(map[string]struct{}, len(vals))
+	for _, val := range vals {
+		size := spaceForLength(len(val)) + len(val)
+		bytesIfPlain += size
+		if _, ok := seen[val]; ok {
+			continue
+		}
+		seen[val] = struct{}{}
+		bytesIfInterned += size
+	}
+	return len(seen), len(vals), bytesIfInterned, bytesIfPlain
+}
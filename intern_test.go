@@ -0,0 +1,225 @@
+package stringbank
+
+import (
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveUnique(t *testing.T) {
+	sb := Stringbank{}
+
+	s1 := sb.SaveUnique("hello")
+	s2 := sb.SaveUnique("goodbye")
+	s3 := sb.SaveUnique("hello")
+	s4 := sb.Save("hello")
+	s5 := sb.SaveUnique("")
+	s6 := sb.SaveUnique("")
+
+	assert.Equal(t, s1, s3)
+	assert.NotEqual(t, s1, s2)
+	assert.NotEqual(t, s1, s4)
+	assert.Equal(t, s5, s6)
+	assert.Equal(t, "hello", sb.Get(s1))
+	assert.Equal(t, "goodbye", sb.Get(s2))
+	assert.Equal(t, "", sb.Get(s5))
+}
+
+func TestSaveUniqueCollisions(t *testing.T) {
+	// Force every string to have the same hash. We save few enough strings that the table doesn't grow, as that
+	// would rehash the strings with their real hashes
+	sb := Stringbank{}
+	indices := make([]int, 40)
+	for i := range indices {
+		indices[i] = sb.saveUnique(strconv.Itoa(i), 42)
+	}
+	for i, index := range indices {
+		assert.Equal(t, index, sb.saveUnique(strconv.Itoa(i), 42))
+		assert.Equal(t, strconv.Itoa(i), sb.Get(index))
+	}
+	assert.Equal(t, 40, sb.Len())
+	assert.Equal(t, 64, len(sb.intern.slots))
+}
+
+func TestLookup(t *testing.T) {
+	sb := Stringbank{}
+	_, ok := sb.Lookup("hello")
+	assert.False(t, ok)
+
+	i := sb.SaveUnique("hello")
+	sb.Save("plain")
+	index, ok := sb.Lookup("hello")
+	assert.True(t, ok)
+	assert.Equal(t, i, index)
+
+	// Strings saved with Save are not interned
+	_, ok = sb.Lookup("plain")
+	assert.False(t, ok)
+	assert.Equal(t, 2, sb.Len())
+}
+
+func TestMergeInterned(t *testing.T) {
+	a := Stringbank{}
+	b := Stringbank{}
+	for i := 0; i < 100; i++ {
+		a.SaveUnique(strconv.Itoa(i))
+		b.SaveUnique(strconv.Itoa(i + 50))
+	}
+	a.SaveUnique("0")
+	a.SaveUnique("1")
+
+	moves := map[int]int{}
+	a.MergeInterned(&b, func(oldIndex, newIndex int) {
+		moves[oldIndex] = newIndex
+	})
+	assert.Equal(t, 150, a.Len())
+	assert.Len(t, moves, 100)
+	for oldIndex, newIndex := range moves {
+		assert.Equal(t, b.Get(oldIndex), a.Get(newIndex))
+	}
+
+	// Strings only in b are now interned in a
+	index, ok := a.Lookup("149")
+	assert.True(t, ok)
+	assert.Equal(t, "149", a.Get(index))
+	assert.Equal(t, index, a.SaveUnique("149"))
+
+	unique, hits := a.InternStats()
+	assert.Equal(t, 150, unique)
+	assert.Equal(t, 3, hits)
+}
+
+func TestInternStats(t *testing.T) {
+	sb := Stringbank{}
+	unique, hits := sb.InternStats()
+	assert.Zero(t, unique)
+	assert.Zero(t, hits)
+
+	for _, v := range []string{"a", "b", "a", "c", "a", "b"} {
+		sb.SaveUnique(v)
+	}
+	sb.Save("a")
+
+	unique, hits = sb.InternStats()
+	assert.Equal(t, 3, unique)
+	assert.Equal(t, 3, hits)
+}
+
+func TestDistinctCount(t *testing.T) {
+	sb := Stringbank{}
+	assert.Zero(t, sb.DistinctCount())
+	for i := 0; i < 1000; i++ {
+		sb.Save(strconv.Itoa(i % 37))
+	}
+	sb.Save("")
+	sb.Save("")
+	assert.Equal(t, 38, sb.DistinctCount())
+	assert.Equal(t, 1002, sb.Len())
+	// The bank's own intern table is not touched
+	assert.Zero(t, sb.intern.count)
+}
+
+func TestSaveLongest(t *testing.T) {
+	sb := Stringbank{}
+
+	tests := []struct {
+		key       string
+		candidate string
+		exp       string
+	}{
+		{"a", "x", "x"},
+		{"a", "xyz", "xyz"},
+		{"a", "xy", "xyz"},
+		{"b", "", ""},
+		{"a", "abc", "xyz"},
+		{"b", "hello", "hello"},
+		{"a", "longest", "longest"},
+		{"b", "bye", "hello"},
+	}
+	for _, test := range tests {
+		index := sb.SaveLongest(test.key, test.candidate)
+		assert.Equal(t, test.exp, sb.Get(index))
+	}
+
+	assert.Equal(t, "longest", sb.Get(sb.SaveLongest("a", "")))
+	assert.Equal(t, "hello", sb.Get(sb.SaveLongest("b", "")))
+}
+
+func TestSaveUniqueMany(t *testing.T) {
+	sb := Stringbank{}
+	indices := make([]int, 100000)
+	for i := range indices {
+		indices[i] = sb.SaveUnique(strconv.Itoa(i))
+	}
+	size := sb.Size()
+	for i, index := range indices {
+		assert.Equal(t, index, sb.SaveUnique(strconv.Itoa(i)))
+		assert.Equal(t, strconv.Itoa(i), sb.Get(index))
+	}
+	assert.Equal(t, size, sb.Size())
+}
+
+func TestInternAll(t *testing.T) {
+	// Make sure the work is split across several workers, however many CPUs we have
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+
+	vals := make([]string, 200000)
+	for i := range vals {
+		vals[i] = strconv.Itoa((i * 7919) % 1000)
+	}
+
+	serial := Stringbank{}
+	exp := make([]int, len(vals))
+	for i, val := range vals {
+		exp[i] = serial.SaveUnique(val)
+	}
+
+	sb := Stringbank{}
+	indices := sb.InternAll(vals)
+	assert.Equal(t, exp, indices)
+	for i, index := range indices {
+		assert.Equal(t, vals[i], sb.Get(index))
+	}
+	assert.Equal(t, 1000, sb.count)
+	unique, hits := sb.InternStats()
+	expUnique, expHits := serial.InternStats()
+	assert.Equal(t, expUnique, unique)
+	assert.Equal(t, expHits, hits)
+
+	// Interning again should find everything already present
+	again := sb.InternAll(vals)
+	assert.Equal(t, indices, again)
+	assert.Equal(t, 1000, sb.count)
+	_, hits = sb.InternStats()
+	assert.Equal(t, expHits+len(vals), hits)
+}
+
+func TestEstimateDedup(t *testing.T) {
+	var vals []string
+	for i := 0; i < 1000; i++ {
+		// 10 distinct strings of 2 bytes each, each appearing 100 times
+		vals = append(vals, strconv.Itoa(10+i%10))
+	}
+	vals = append(vals, "")
+
+	distinct, total, interned, plain := EstimateDedup(vals)
+	assert.Equal(t, 11, distinct)
+	assert.Equal(t, 1001, total)
+	assert.Equal(t, 10*3+1, interned)
+	assert.Equal(t, 1000*3+1, plain)
+
+	// The estimates match what a bank actually uses
+	sb := Stringbank{}
+	for _, v := range vals {
+		sb.SaveUnique(v)
+	}
+	assert.Equal(t, interned, sb.Used())
+
+	distinct, total, interned, plain = EstimateDedup(nil)
+	assert.Zero(t, distinct)
+	assert.Zero(t, total)
+	assert.Zero(t, interned)
+	assert.Zero(t, plain)
+}
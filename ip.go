@@ -0,0 +1,28 @@
+package stringbank
+
+import "net"
+
+// SaveIP stores an IP address in the Stringbank in its raw 4 byte (IPv4) or 16 byte (IPv6) form, which is much
+// more compact than its textual form. It returns the index of the address, which can be converted back into a
+// net.IP with GetIP. Invalid addresses are saved as empty
+func (s *Stringbank) SaveIP(ip net.IP) int {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	} else {
+		ip = ip.To16()
+	}
+	offset, buf := s.alloc(len(ip))
+	copy(buf, ip)
+	return s.logged(offset)
+}
+
+// GetIP returns the IP address saved by SaveIP at index. The length of the returned address is 4 for an IPv4
+// address and 16 for an IPv6 address. An address that was invalid when saved is returned as nil. The returned
+// address is a copy, so may be modified freely
+func (s *Stringbank) GetIP(index int) net.IP {
+	val := s.Get(index)
+	if len(val) == 0 {
+		return nil
+	}
+	return net.IP(val)
+}
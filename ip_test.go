@@ -0,0 +1,44 @@
+package stringbank
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveIP(t *testing.T) {
+	tests := []struct {
+		name string
+		ip   net.IP
+		len  int
+	}{
+		{"v4", net.ParseIP("192.168.1.1"), 4},
+		{"v4 raw", net.IPv4(10, 0, 0, 1).To4(), 4},
+		{"v6", net.ParseIP("2001:db8::68"), 16},
+		{"v6 loopback", net.IPv6loopback, 16},
+		{"invalid", net.IP{1, 2, 3}, 0},
+	}
+
+	sb := Stringbank{}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			index := sb.SaveIP(test.ip)
+			ip := sb.GetIP(index)
+			assert.Len(t, ip, test.len)
+			if test.len != 0 {
+				assert.True(t, test.ip.Equal(ip))
+			}
+			assert.Equal(t, test.len, len(sb.Get(index)))
+		})
+	}
+}
+
+func TestGetIPCopy(t *testing.T) {
+	sb := Stringbank{}
+	index := sb.SaveIP(net.IPv4(10, 0, 0, 1))
+
+	ip := sb.GetIP(index)
+	ip[0] = 11
+	assert.Equal(t, "10.0.0.1", sb.GetIP(index).String())
+}
@@ -0,0 +1,38 @@
+package stringbank
+
+import "sort"
+
+// keyedEntry records the sort key of a string saved by SaveKeyed
+type keyedEntry struct {
+	key   uint32
+	index int
+}
+
+// SaveKeyed copies a string into the Stringbank along with a sort key, and returns the index of the string in the
+// bank. Strings saved with SaveKeyed can be enumerated in sort key order with ForEachByKey. Saving in key order is
+// cheapest, as a string saved out of order is inserted into the sorted keys.
+func (s *Stringbank) SaveKeyed(val string, sortKey uint32) int {
+	index := s.Save(val)
+	e := keyedEntry{key: sortKey, index: index}
+	if n := len(s.keyed); n == 0 || s.keyed[n-1].key <= sortKey {
+		s.keyed = append(s.keyed, e)
+		return index
+	}
+	// Insert after any strings with the same key, so strings with equal keys stay in save order
+	i := sort.Search(len(s.keyed), func(i int) bool { return s.keyed[i].key > sortKey })
+	s.keyed = append(s.keyed, keyedEntry{})
+	copy(s.keyed[i+1:], s.keyed[i:])
+	s.keyed[i] = e
+	return index
+}
+
+// ForEachByKey calls fn for each string saved by SaveKeyed in order of the strings' sort keys. Strings with the
+// same sort key are visited in the order they were saved. Iteration stops early if fn returns false. ForEachByKey
+// does not modify the bank, so may be called concurrently by readers of a bank that is no longer being changed.
+func (s *Stringbank) ForEachByKey(fn func(sortKey uint32, index int, val string) bool) {
+	for _, e := range s.keyed {
+		if !fn(e.key, e.index, s.Get(e.index)) {
+			return
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package stringbank
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForEachByKey(t *testing.T) {
+	sb := Stringbank{}
+	sb.SaveKeyed("c", 30)
+	sb.SaveKeyed("a", 10)
+	sb.Save("not keyed")
+	sb.SaveKeyed("b1", 20)
+	sb.SaveKeyed("d", 40)
+	sb.SaveKeyed("b2", 20)
+
+	var vals []string
+	var keys []uint32
+	sb.ForEachByKey(func(sortKey uint32, index int, val string) bool {
+		assert.Equal(t, val, sb.Get(index))
+		vals = append(vals, val)
+		keys = append(keys, sortKey)
+		return true
+	})
+	assert.Equal(t, []string{"a", "b1", "b2", "c", "d"}, vals)
+	assert.Equal(t, []uint32{10, 20, 20, 30, 40}, keys)
+
+	// Adding more after iterating keeps save order for equal keys
+	sb.SaveKeyed("b3", 20)
+	sb.SaveKeyed("e", 0)
+	vals = nil
+	sb.ForEachByKey(func(sortKey uint32, index int, val string) bool {
+		vals = append(vals, val)
+		return len(vals) < 5
+	})
+	assert.Equal(t, []string{"e", "a", "b1", "b2", "b3"}, vals)
+}
+
+func TestForEachByKeyConcurrent(t *testing.T) {
+	sb := Stringbank{}
+	for i := 0; i < 1000; i++ {
+		sb.SaveKeyed(strconv.Itoa(i), uint32(1000-i))
+	}
+	sb.Freeze()
+
+	// Readers of a published bank don't change it, so can iterate at the same time
+	var wg sync.WaitGroup
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var prev uint32
+			sb.ForEachByKey(func(sortKey uint32, index int, val string) bool {
+				assert.True(t, sortKey >= prev)
+				prev = sortKey
+				return true
+			})
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,179 @@
+package stringbank
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// A save log starts with a short header, and is followed by a record for each string saved and for each new
+// allocation the bank makes. Each record starts with a tag byte. A string record is followed by the string's length
+// as a uvarint and its bytes, which is exactly how the string is held in the bank. A chunk record is followed by the
+// size of the new allocation as a uvarint, and the strings that follow are placed in it until the next chunk record.
+//
+//	magic     "SBLG"
+//	version   1 byte
+//	chunkSize uvarint
+//
+// The chunk size and chunk records are needed so the rebuilt bank gives each string the same index as the
+// original, however its chunks were laid out.
+const (
+	logMagic   = "SBLG"
+	logVersion = 1
+)
+
+// Tags for the records in a save log
+const (
+	logStringRecord = 0
+	logChunkRecord  = 1
+)
+
+// SetLog starts recording each string saved in the Stringbank to w, so that the bank can be rebuilt by ReplayLog.
+// Any strings already in the bank are written to the log first. Unlike WriteTo the log is append-only, so can be
+// used to persist a bank incrementally as it is built. Writes are not buffered, so consider wrapping w in a
+// bufio.Writer. If a write fails logging stops, and the error is reported by LogErr.
+func (s *Stringbank) SetLog(w io.Writer) {
+	s.log = w
+	s.logErr = nil
+	var header [len(logMagic) + 1 + binary.MaxVarintLen64]byte
+	n := copy(header[:], logMagic)
+	header[n] = logVersion
+	n++
+	n += binary.PutUvarint(header[n:], uint64(s.chunkSize()))
+	if _, err := w.Write(header[:n]); err != nil {
+		s.logErr = err
+		return
+	}
+	chunkSize := s.chunkSize()
+	for slot, data := range s.allocations {
+		if data == nil {
+			continue
+		}
+		// The allocation is followed by nil entries for the rest of its index space. A chunk read by ReadFrom may
+		// take up more index space than its capacity
+		end := slot + 1
+		for end < len(s.allocations) && s.allocations[end] == nil {
+			end++
+		}
+		size := cap(data)
+		if span := (end - slot) * chunkSize; size <= span-chunkSize {
+			size = span
+		}
+		s.logChunk(size)
+		for offset := 0; offset < len(data) && s.logErr == nil; {
+			l, llen := readLength(data[offset:])
+			s.logged(slot*chunkSize + offset)
+			offset += llen + l
+		}
+		if s.logErr != nil {
+			return
+		}
+	}
+}
+
+// LogErr returns the first error encountered writing to the log set by SetLog
+func (s *Stringbank) LogErr() error {
+	return s.logErr
+}
+
+// logged writes the string at index to the save log, if there is one. It returns index
+func (s *Stringbank) logged(index int) int {
+	if s.log == nil || s.logErr != nil {
+		return index
+	}
+	slot, offset := s.locate(index)
+	data := s.allocations[slot]
+	l, llen := readLength(data[offset:])
+	s.logBuf[0] = logStringRecord
+	if _, err := s.log.Write(s.logBuf[:1]); err != nil {
+		s.logErr = err
+		return index
+	}
+	if _, err := s.log.Write(data[offset : offset+llen+l]); err != nil {
+		s.logErr = err
+	}
+	return index
+}
+
+// logChunk writes a record of a new allocation of size bytes to the save log, if there is one
+func (s *Stringbank) logChunk(size int) {
+	if s.log == nil || s.logErr != nil {
+		return
+	}
+	s.logBuf[0] = logChunkRecord
+	n := 1 + binary.PutUvarint(s.logBuf[1:], uint64(size))
+	if _, err := s.log.Write(s.logBuf[:n]); err != nil {
+		s.logErr = err
+	}
+}
+
+// ReplayLog rebuilds a Stringbank from a log recorded via SetLog. It returns the new bank and the index of each
+// string in the order the strings were saved. These indices are identical to those in the original bank
+func ReplayLog(r io.Reader) (*Stringbank, []int, error) {
+	br := bufio.NewReader(r)
+	var magic [len(logMagic)]byte
+	if _, err := io.ReadFull(br, magic[:]); err != nil || string(magic[:]) != logMagic {
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return nil, nil, err
+		}
+		return nil, nil, ErrBadMagic
+	}
+	version, err := br.ReadByte()
+	if err != nil {
+		return nil, nil, noEOF(err)
+	}
+	if version != logVersion {
+		return nil, nil, fmt.Errorf("stringbank: unsupported log version %d", version)
+	}
+	chunkSize, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, nil, noEOF(err)
+	}
+	if chunkSize > uint64(maxInt) || checkChunkSize(int(chunkSize)) != nil {
+		return nil, nil, fmt.Errorf("stringbank: log has invalid chunk size %d", chunkSize)
+	}
+
+	s := &Stringbank{chunk: int(chunkSize)}
+	var indices []int
+	for {
+		tag, err := br.ReadByte()
+		if err == io.EOF {
+			return s, indices, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		switch tag {
+		case logChunkRecord:
+			size, err := binary.ReadUvarint(br)
+			if err != nil {
+				return nil, nil, noEOF(err)
+			}
+			if size == 0 || size > uint64(maxInt) {
+				return nil, nil, fmt.Errorf("stringbank: log has invalid allocation size %d", size)
+			}
+			s.startChunk(int(size))
+		case logStringRecord:
+			l, err := binary.ReadUvarint(br)
+			if err != nil {
+				return nil, nil, noEOF(err)
+			}
+			if l > uint64(maxInt-binary.MaxVarintLen64) {
+				return nil, nil, fmt.Errorf("stringbank: log has invalid string length %d", l)
+			}
+			// Strings are placed exactly where the chunk records say, rather than wherever reserve would put them
+			total := int(l) + spaceForLength(int(l))
+			if total > cap(s.current)-len(s.current) {
+				return nil, nil, fmt.Errorf("stringbank: log has string of length %d that does not fit its chunk", l)
+			}
+			index, buf := s.reserveCurrent(total)
+			if _, err := io.ReadFull(br, s.addString(int(l), index, buf)); err != nil {
+				return nil, nil, noEOF(err)
+			}
+			indices = append(indices, index)
+		default:
+			return nil, nil, fmt.Errorf("stringbank: unknown log record %d", tag)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package stringbank
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayLog(t *testing.T) {
+	sb := Stringbank{}
+	var indices []int
+	indices = append(indices, sb.Save("before the log"))
+
+	var log bytes.Buffer
+	sb.SetLog(&log)
+	for i := 0; i < 100000; i++ {
+		indices = append(indices, sb.Save(strconv.Itoa(i)))
+	}
+	indices = append(indices, sb.Save(strings.Repeat("a", 1000)))
+	indices = append(indices, sb.Save(""))
+	indices = append(indices, sb.SaveIP(net.IPv4(10, 0, 0, 1)))
+	indices = append(indices, sb.SaveUnique("unique"))
+	sb.SaveUnique("unique")
+	require.NoError(t, sb.LogErr())
+
+	replayed, replayedIndices, err := ReplayLog(&log)
+	require.NoError(t, err)
+	assert.Equal(t, indices, replayedIndices)
+	for _, index := range indices {
+		assert.Equal(t, sb.Get(index), replayed.Get(index))
+	}
+	assert.Equal(t, sb.allocations, replayed.allocations)
+}
+
+func TestReplayLogChunkSize(t *testing.T) {
+	sb := New(64)
+	var log bytes.Buffer
+	sb.SetLog(&log)
+	var indices []int
+	for i := 0; i < 1000; i++ {
+		indices = append(indices, sb.Save(strconv.Itoa(i)))
+	}
+	indices = append(indices, sb.Save(strings.Repeat("a", 100)), sb.Save("after"))
+	require.NoError(t, sb.LogErr())
+
+	replayed, replayedIndices, err := ReplayLog(&log)
+	require.NoError(t, err)
+	assert.Equal(t, indices, replayedIndices)
+	for _, index := range indices {
+		assert.Equal(t, sb.Get(index), replayed.Get(index))
+	}
+	assert.Equal(t, 64, replayed.chunkSize())
+}
+
+func TestReplayLogGroups(t *testing.T) {
+	sb := New(64)
+	var indices []int
+	save := func(val string) {
+		indices = append(indices, sb.Save(val))
+	}
+	// Groups before the log is set, which SetLog must reproduce
+	save("before")
+	sb.BeginGroup(64)
+	save("grouped before")
+	sb.EndGroup()
+
+	var log bytes.Buffer
+	sb.SetLog(&log)
+	save("a")
+	sb.BeginGroup(64)
+	save("b")
+	save("c")
+	sb.EndGroup()
+	save(strings.Repeat("x", 100))
+	indices = append(indices, sb.SaveCleanPath("/a/./b/../"+strings.Repeat("c", 200)))
+	save("after")
+	require.NoError(t, sb.LogErr())
+
+	replayed, replayedIndices, err := ReplayLog(&log)
+	require.NoError(t, err)
+	assert.Equal(t, indices, replayedIndices)
+	for _, index := range indices {
+		assert.Equal(t, sb.Get(index), replayed.Get(index))
+	}
+	assert.Equal(t, sb.Size(), replayed.Size())
+}
+
+func TestReplayLogBadRecords(t *testing.T) {
+	_, _, err := ReplayLog(strings.NewReader("SBLG\x01\x40\x02"))
+	assert.EqualError(t, err, "stringbank: unknown log record 2")
+	_, _, err = ReplayLog(strings.NewReader("SBLG\x01\x40\x01\x00"))
+	assert.EqualError(t, err, "stringbank: log has invalid allocation size 0")
+	_, _, err = ReplayLog(strings.NewReader("SBLG\x01\x40\x01\x04\x00\x04abcd"))
+	assert.EqualError(t, err, "stringbank: log has string of length 4 that does not fit its chunk")
+	_, _, err = ReplayLog(strings.NewReader("SBLG\x01\x40\x01\x40\x00\xff\xff\xff\xff\xff\xff\xff\xff\xff\x01"))
+	assert.EqualError(t, err, "stringbank: log has invalid string length 18446744073709551615")
+	_, _, err = ReplayLog(strings.NewReader("SBLG\x01\x40\x01\x40\x00\xff\xff\xff\xff\xff\xff\xff\xff\x7f"))
+	assert.EqualError(t, err, "stringbank: log has invalid string length 9223372036854775807")
+}
+
+func TestReplayLogBadHeader(t *testing.T) {
+	_, _, err := ReplayLog(strings.NewReader("SBLG\x02\x40"))
+	assert.EqualError(t, err, "stringbank: unsupported log version 2")
+	_, _, err = ReplayLog(strings.NewReader("SBLG\x01\x41"))
+	assert.EqualError(t, err, "stringbank: log has invalid chunk size 65")
+	_, _, err = ReplayLog(strings.NewReader("SBLG\x01"))
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+}
+
+func TestReplayLogBadMagic(t *testing.T) {
+	_, _, err := ReplayLog(strings.NewReader("SBNK"))
+	assert.Equal(t, ErrBadMagic, err)
+	_, _, err = ReplayLog(strings.NewReader(""))
+	assert.Equal(t, ErrBadMagic, err)
+}
+
+func TestReplayLogTruncated(t *testing.T) {
+	sb := Stringbank{}
+	var log bytes.Buffer
+	sb.SetLog(&log)
+	sb.Save("hello")
+
+	_, _, err := ReplayLog(bytes.NewReader(log.Bytes()[:log.Len()-1]))
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestLogErr(t *testing.T) {
+	sb := Stringbank{}
+	sb.SetLog(failingWriter{})
+	s1 := sb.Save("hello")
+	assert.EqualError(t, sb.LogErr(), "write failed")
+	assert.Equal(t, "hello", sb.Get(s1))
+}
@@ -1,8 +1,5 @@
 module github.com/philpearl/stringbank/offheap
 
-go 1.12
+go 1.16
 
-require (
-	github.com/philpearl/mmap v0.0.0-20190501094812-b5dc52c98503
-	github.com/stretchr/testify v1.3.0
-)
+require github.com/stretchr/testify v1.3.0
@@ -0,0 +1,159 @@
+package offheap
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"unsafe"
+)
+
+// The indexed format is written by WriteIndexed, here or in the heap-based stringbank package. It suits banks that
+// are only ever read, as any string can be found by ordinal without walking the strings before it. The strings are
+// held one after another without length prefixes, and a table of their offsets precedes them. All integers are
+// little-endian.
+//
+//	magic     "SBIX"
+//	version   1 byte
+//	padding   3 bytes
+//	count     uint64
+//	offsets   (count+1) x uint64
+//	data
+//
+// String i runs from offsets[i] to offsets[i+1], measured from the start of the data.
+const (
+	indexedMagic      = "SBIX"
+	indexedVersion    = 1
+	indexedHeaderSize = 16
+)
+
+// WriteIndexed writes the strings in the Stringbank to w in a read-optimized format, in the order they were saved.
+// The result can be opened with OpenIndexed. Unlike WriteTo, indices are not preserved: strings are instead found
+// by their position in the order of saves.
+func (s *Stringbank) WriteIndexed(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	var buf [8]byte
+	bw.WriteString(indexedMagic)
+	bw.Write([]byte{indexedVersion, 0, 0, 0})
+	binary.LittleEndian.PutUint64(buf[:], uint64(s.Len()))
+	bw.Write(buf[:])
+
+	var offset uint64
+	writeOffset := func() {
+		binary.LittleEndian.PutUint64(buf[:], offset)
+		bw.Write(buf[:])
+	}
+	s.ForEach(func(index int, val string) bool {
+		writeOffset()
+		offset += uint64(len(val))
+		return true
+	})
+	writeOffset()
+
+	s.ForEach(func(index int, val string) bool {
+		bw.WriteString(val)
+		return true
+	})
+	return bw.Flush()
+}
+
+// Indexed is a read-only bank opened from a file written by WriteIndexed. The file is mapped into memory, and
+// strings are read directly from the mapping. Close must be called to unmap the file.
+type Indexed struct {
+	mapping []byte
+	offsets []byte
+	data    []byte
+	count   int
+}
+
+// OpenIndexed opens a file written by WriteIndexed
+func OpenIndexed(path string) (*Indexed, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	// The mapping remains valid once the file is closed
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if fi.Size() < indexedHeaderSize+8 {
+		return nil, ErrBadMagic
+	}
+	mapping, err := mapFile(f, 0, int(fi.Size()))
+	if err != nil {
+		return nil, err
+	}
+	ix, err := parseIndexed(mapping)
+	if err != nil {
+		unmapFile(mapping)
+		return nil, err
+	}
+	return ix, nil
+}
+
+// parseIndexed checks the header and offset table of an indexed file held in mapping
+func parseIndexed(mapping []byte) (*Indexed, error) {
+	if string(mapping[:len(indexedMagic)]) != indexedMagic {
+		return nil, ErrBadMagic
+	}
+	if version := mapping[len(indexedMagic)]; version != indexedVersion {
+		return nil, fmt.Errorf("offheap: unsupported indexed file version %d", version)
+	}
+	count := binary.LittleEndian.Uint64(mapping[8:indexedHeaderSize])
+	if count >= uint64(len(mapping)-indexedHeaderSize)/8 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	tableSize := (int(count) + 1) * 8
+	ix := &Indexed{
+		mapping: mapping,
+		offsets: mapping[indexedHeaderSize : indexedHeaderSize+tableSize],
+		data:    mapping[indexedHeaderSize+tableSize:],
+		count:   int(count),
+	}
+	if end := binary.LittleEndian.Uint64(ix.offsets[tableSize-8:]); end != uint64(len(ix.data)) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	// At trusts the offsets, so check each string lies within the data. As the last offset is the end of the data,
+	// offsets that never decrease are all in range
+	var prev uint64
+	for i := 0; i < tableSize; i += 8 {
+		offset := binary.LittleEndian.Uint64(ix.offsets[i:])
+		if offset < prev {
+			return nil, io.ErrUnexpectedEOF
+		}
+		prev = offset
+	}
+	return ix, nil
+}
+
+// Len returns the number of strings in the file
+func (ix *Indexed) Len() int {
+	return ix.count
+}
+
+// At returns the string at the given zero-based position in the order the strings were saved. The string points
+// into the mapped file, so must not be used after Close. At panics if ordinal is out of range
+func (ix *Indexed) At(ordinal int) string {
+	if ordinal < 0 || ordinal >= ix.count {
+		panic("offheap: ordinal out of range")
+	}
+	start := binary.LittleEndian.Uint64(ix.offsets[ordinal*8:])
+	end := binary.LittleEndian.Uint64(ix.offsets[ordinal*8+8:])
+	b := ix.data[start:end]
+	return *(*string)(unsafe.Pointer(&b))
+}
+
+// Close unmaps the file
+func (ix *Indexed) Close() error {
+	if ix.mapping == nil {
+		return nil
+	}
+	err := unmapFile(ix.mapping)
+	ix.mapping, ix.offsets, ix.data, ix.count = nil, nil, nil, 0
+	return err
+}
@@ -0,0 +1,143 @@
+package offheap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteIndexed(t *testing.T) {
+	sb := New(1 << 12)
+	defer sb.Close()
+	var vals []string
+	for i := 0; i < 100000; i++ {
+		v := strconv.Itoa(i)
+		switch i % 10000 {
+		case 0:
+			v = strings.Repeat(v, 3000)
+		case 1:
+			v = ""
+		}
+		sb.Save(v)
+		vals = append(vals, v)
+	}
+
+	path := filepath.Join(t.TempDir(), "indexed")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, sb.WriteIndexed(f))
+	require.NoError(t, f.Close())
+
+	ix, err := OpenIndexed(path)
+	require.NoError(t, err)
+	defer ix.Close()
+	assert.Equal(t, len(vals), ix.Len())
+
+	var ordinal int
+	sb.ForEach(func(index int, val string) bool {
+		assert.Equal(t, val, ix.At(ordinal))
+		ordinal++
+		return true
+	})
+	for _, ordinal := range []int{99999, 0, 50001, 12345} {
+		assert.Equal(t, vals[ordinal], ix.At(ordinal))
+	}
+	assert.Panics(t, func() { ix.At(len(vals)) })
+	assert.Panics(t, func() { ix.At(-1) })
+
+	assert.NoError(t, ix.Close())
+	assert.NoError(t, ix.Close())
+}
+
+func TestOpenIndexedGolden(t *testing.T) {
+	// testdata/indexed.sbix is written by WriteIndexed in the heap-based stringbank package
+	const path = "testdata/indexed.sbix"
+	var sb Stringbank
+	defer sb.Close()
+	var vals []string
+	for i := 0; i < 1000; i++ {
+		v := strconv.Itoa(i)
+		switch i % 100 {
+		case 0:
+			v = strings.Repeat(v, 100)
+		case 1:
+			v = ""
+		}
+		sb.Save(v)
+		vals = append(vals, v)
+	}
+
+	ix, err := OpenIndexed(path)
+	require.NoError(t, err)
+	defer ix.Close()
+	assert.Equal(t, len(vals), ix.Len())
+	for i, v := range vals {
+		assert.Equal(t, v, ix.At(i))
+	}
+
+	// Both packages write the same format
+	var buf bytes.Buffer
+	require.NoError(t, sb.WriteIndexed(&buf))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.True(t, bytes.Equal(data, buf.Bytes()))
+}
+
+func TestWriteIndexedEmpty(t *testing.T) {
+	var sb Stringbank
+	path := filepath.Join(t.TempDir(), "indexed")
+	var buf bytes.Buffer
+	require.NoError(t, sb.WriteIndexed(&buf))
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0600))
+
+	ix, err := OpenIndexed(path)
+	require.NoError(t, err)
+	defer ix.Close()
+	assert.Equal(t, 0, ix.Len())
+}
+
+func TestOpenIndexedBadFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bad")
+	require.NoError(t, os.WriteFile(path, []byte("not an indexed bank at all"), 0600))
+	_, err := OpenIndexed(path)
+	assert.Equal(t, ErrBadMagic, err)
+
+	require.NoError(t, os.WriteFile(path, []byte("short"), 0600))
+	_, err = OpenIndexed(path)
+	assert.Equal(t, ErrBadMagic, err)
+
+	var sb Stringbank
+	defer sb.Close()
+	sb.Save("hello")
+	sb.Save("world")
+	var buf bytes.Buffer
+	require.NoError(t, sb.WriteIndexed(&buf))
+	require.NoError(t, os.WriteFile(path, buf.Bytes()[:buf.Len()-1], 0600))
+	_, err = OpenIndexed(path)
+	assert.Error(t, err)
+
+	// A count far larger than the file holds
+	data := append([]byte(nil), buf.Bytes()...)
+	data[15] = 0x10
+	require.NoError(t, os.WriteFile(path, data, 0600))
+	_, err = OpenIndexed(path)
+	assert.Error(t, err)
+
+	// Offsets that run backwards or past the end of the data
+	for _, offset := range []uint64{11, 1 << 62} {
+		data = append([]byte(nil), buf.Bytes()...)
+		binary.LittleEndian.PutUint64(data[indexedHeaderSize+8:], offset)
+		require.NoError(t, os.WriteFile(path, data, 0600))
+		_, err = OpenIndexed(path)
+		assert.Equal(t, io.ErrUnexpectedEOF, err)
+	}
+}
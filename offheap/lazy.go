@@ -0,0 +1,164 @@
+package offheap
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+)
+
+// lazyFile tracks the chunks of a Stringbank opened with OpenLazy
+type lazyFile struct {
+	f         *os.File
+	chunkSize int
+	// offsets and lengths locate the data for each chunk within the file
+	offsets []int64
+	lengths []int
+	// mappings holds the memory mapped for each chunk, which may start before the chunk data as mappings must
+	// start on a page boundary. nil if the chunk has not been mapped yet
+	mappings [][]byte
+	// count is the number of strings in the file, or -1 if they have not been counted yet
+	count int
+}
+
+// OpenLazy opens a Stringbank that was serialized to the file at path. The file is mapped directly rather than
+// being copied into memory, and chunks of the file are mapped only when a string within them is first read, so
+// memory use is low if only a few strings are ever accessed. Indices from the original bank remain valid. Strings
+// saved after opening are held in newly allocated memory, as with any other Stringbank. Because Get may map
+// chunks, a lazily opened Stringbank is not safe for concurrent use. Close unmaps the file. On platforms without
+// mmap each chunk is read into memory when first needed instead.
+func OpenLazy(path string) (*Stringbank, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	lf, err := scanFile(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	s := &Stringbank{
+		chunk:       lf.chunkSize,
+		allocations: make([][]byte, len(lf.offsets)),
+		lazy:        lf,
+	}
+	for _, length := range lf.lengths {
+		if length > lf.chunkSize {
+			// Strings may start beyond the first chunk's worth of this chunk's data
+			s.buildStarts()
+			break
+		}
+	}
+	return s, nil
+}
+
+// buildStarts fills in starts from the lengths of the chunks in the file. Each empty chunk is covered by the
+// chunk before it
+func (s *Stringbank) buildStarts() {
+	s.starts = make([]int, len(s.lazy.lengths))
+	var start int
+	for i, length := range s.lazy.lengths {
+		if length > 0 {
+			start = i
+		}
+		s.starts[i] = start
+	}
+}
+
+// scanFile reads the header of the file and finds where each chunk's data lies
+func scanFile(f *os.File) (*lazyFile, error) {
+	var buf [len(fileMagic) + 1 + 2*binary.MaxVarintLen64]byte
+	n, err := f.ReadAt(buf[:], 0)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	header := buf[:n]
+	if len(header) < len(fileMagic)+1 || string(header[:len(fileMagic)]) != fileMagic {
+		return nil, ErrBadMagic
+	}
+	if version := header[len(fileMagic)]; version != fileVersion {
+		return nil, fmt.Errorf("offheap: unsupported file version %d", version)
+	}
+	pos := len(fileMagic) + 1
+	fileChunkSize, l := binary.Uvarint(header[pos:])
+	if l <= 0 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	pos += l
+	numChunks, l := binary.Uvarint(header[pos:])
+	if l <= 0 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	pos += l
+	if err := checkChunkSize(int(fileChunkSize)); err != nil {
+		return nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := fi.Size()
+	// Each chunk needs at least a byte for its length, so a count larger than the rest of the file is corrupt
+	if numChunks > uint64(size-int64(pos)) {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	lf := &lazyFile{
+		f:         f,
+		chunkSize: int(fileChunkSize),
+		offsets:   make([]int64, numChunks),
+		lengths:   make([]int, numChunks),
+		mappings:  make([][]byte, numChunks),
+		count:     -1,
+	}
+	offset := int64(pos)
+	for i := range lf.offsets {
+		n, err := f.ReadAt(buf[:binary.MaxVarintLen64], offset)
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		used, l := binary.Uvarint(buf[:n])
+		if l <= 0 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		lf.offsets[i] = offset + int64(l)
+		if used > uint64(size-lf.offsets[i]) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		lf.lengths[i] = int(used)
+		offset = lf.offsets[i] + int64(used)
+	}
+	return lf, nil
+}
+
+// mapChunk maps chunk into memory and returns its data
+func (s *Stringbank) mapChunk(chunk int) []byte {
+	lf := s.lazy
+	if chunk >= len(lf.offsets) || lf.lengths[chunk] == 0 {
+		panic("offheap: index out of range")
+	}
+	pageSize := int64(os.Getpagesize())
+	start := lf.offsets[chunk] / pageSize * pageSize
+	skip := int(lf.offsets[chunk] - start)
+	mapping, err := mapFile(lf.f, start, skip+lf.lengths[chunk])
+	if err != nil {
+		panic(fmt.Sprintf("offheap: failed to map chunk %d: %v", chunk, err))
+	}
+	lf.mappings[chunk] = mapping
+	data := mapping[skip:]
+	s.allocations[chunk] = data
+	return data
+}
+
+// close unmaps any chunks that have been mapped and closes the file
+func (lf *lazyFile) close() error {
+	for i, mapping := range lf.mappings {
+		if mapping != nil {
+			if err := unmapFile(mapping); err != nil {
+				return err
+			}
+			lf.mappings[i] = nil
+		}
+	}
+	return lf.f.Close()
+}
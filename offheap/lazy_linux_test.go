@@ -0,0 +1,47 @@
+package offheap
+
+import (
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// resident returns the number of pages of b that are resident in memory
+func resident(t *testing.T, b []byte) int {
+	pageSize := os.Getpagesize()
+	vec := make([]byte, (len(b)+pageSize-1)/pageSize)
+	_, _, errno := syscall.Syscall(syscall.SYS_MINCORE, uintptr(unsafe.Pointer(&b[0])), uintptr(len(b)), uintptr(unsafe.Pointer(&vec[0])))
+	require.Zero(t, errno)
+	var count int
+	for _, v := range vec {
+		count += int(v & 1)
+	}
+	return count
+}
+
+func TestOpenLazyResident(t *testing.T) {
+	chunks := make([][]string, 4)
+	for i := range chunks {
+		for j := 0; j < 10000; j++ {
+			chunks[i] = append(chunks[i], strconv.Itoa(i)+"-"+strconv.Itoa(j))
+		}
+	}
+	path, indices := writeBankFile(t, chunks)
+
+	sb, err := OpenLazy(path)
+	require.NoError(t, err)
+	defer sb.Close()
+
+	// Only the chunks that have been read are mapped, and reading them brings them into memory
+	assert.Equal(t, "0-17", sb.Get(indices[0][17]))
+	assert.Equal(t, "2-9999", sb.Get(indices[2][9999]))
+	assert.True(t, resident(t, sb.lazy.mappings[0]) > 0)
+	assert.True(t, resident(t, sb.lazy.mappings[2]) > 0)
+	assert.Nil(t, sb.lazy.mappings[1])
+	assert.Nil(t, sb.lazy.mappings[3])
+}
@@ -0,0 +1,285 @@
+package offheap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeBankFile writes a file in the serialized stringbank format with the given strings in each chunk. It
+// returns the path of the file and the index of each string
+func writeBankFile(t *testing.T, chunks [][]string) (string, [][]int) {
+	var buf bytes.Buffer
+	var num [binary.MaxVarintLen64]byte
+	buf.WriteString(fileMagic)
+	buf.WriteByte(fileVersion)
+	buf.Write(num[:binary.PutUvarint(num[:], uint64(defaultChunkSize))])
+	buf.Write(num[:binary.PutUvarint(num[:], uint64(len(chunks)))])
+
+	indices := make([][]int, len(chunks))
+	for i, chunk := range chunks {
+		var data []byte
+		for _, val := range chunk {
+			indices[i] = append(indices[i], i*defaultChunkSize+len(data))
+			data = append(data, num[:binary.PutUvarint(num[:], uint64(len(val)))]...)
+			data = append(data, val...)
+		}
+		require.True(t, len(data) <= defaultChunkSize)
+		buf.Write(num[:binary.PutUvarint(num[:], uint64(len(data)))])
+		buf.Write(data)
+	}
+
+	path := filepath.Join(t.TempDir(), "bank")
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0600))
+	return path, indices
+}
+
+func TestOpenLazy(t *testing.T) {
+	chunks := make([][]string, 4)
+	for i := range chunks {
+		for j := 0; j < 10000; j++ {
+			chunks[i] = append(chunks[i], strconv.Itoa(i)+"-"+strconv.Itoa(j))
+		}
+	}
+	path, indices := writeBankFile(t, chunks)
+
+	sb, err := OpenLazy(path)
+	require.NoError(t, err)
+	defer sb.Close()
+
+	for i := range chunks {
+		assert.Nil(t, sb.lazy.mappings[i])
+	}
+
+	assert.Equal(t, "0-17", sb.Get(indices[0][17]))
+	assert.Equal(t, "2-9999", sb.Get(indices[2][9999]))
+	assert.Equal(t, "2-0", sb.Get(indices[2][0]))
+
+	assert.NotNil(t, sb.lazy.mappings[0])
+	assert.Nil(t, sb.lazy.mappings[1])
+	assert.NotNil(t, sb.lazy.mappings[2])
+	assert.Nil(t, sb.lazy.mappings[3])
+
+	for i, chunk := range chunks {
+		for j, val := range chunk {
+			assert.Equal(t, val, sb.Get(indices[i][j]))
+		}
+	}
+
+	// New strings go into fresh memory after the file's chunks
+	s1 := sb.Save("hello")
+	assert.Equal(t, len(chunks)*defaultChunkSize, s1)
+	assert.Equal(t, "hello", sb.Get(s1))
+}
+
+func TestOpenLazyBadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bank")
+	require.NoError(t, os.WriteFile(path, []byte("not a bank"), 0600))
+	_, err := OpenLazy(path)
+	assert.Equal(t, ErrBadMagic, err)
+
+	path, _ = writeBankFile(t, [][]string{{"hello"}})
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data[:len(data)-1], 0600))
+	_, err = OpenLazy(path)
+	assert.Error(t, err)
+
+	// Corrupt counts and lengths must not be trusted
+	header := fileMagic + "\x01\x80\x80\x10"
+	for _, data := range []string{
+		header + "\xff\xff\xff\xff\xff\xff\xff\xff\x7f",
+		header + "\x02\x00",
+		header + "\x01\xff\xff\xff\xff\xff\xff\xff\xff\xff\x01",
+		header + "\x01\x03ab",
+	} {
+		require.NoError(t, os.WriteFile(path, []byte(data), 0600))
+		_, err = OpenLazy(path)
+		assert.Equal(t, io.ErrUnexpectedEOF, err, "%q", data)
+	}
+}
+
+func TestWriteToOpenLazy(t *testing.T) {
+	sb := Stringbank{}
+	defer sb.Close()
+	var indices []int
+	var vals []string
+	for i := 0; i < 100000; i++ {
+		vals = append(vals, strconv.Itoa(i))
+	}
+	vals = append(vals, "", strings.Repeat("a", 300), strings.Repeat("b", 3*defaultChunkSize), "after")
+	for _, v := range vals {
+		indices = append(indices, sb.Save(v))
+	}
+
+	path := filepath.Join(t.TempDir(), "bank")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	n, err := sb.WriteTo(f)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	fi, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, fi.Size(), n)
+
+	loaded, err := OpenLazy(path)
+	require.NoError(t, err)
+	defer loaded.Close()
+	for i, index := range indices {
+		assert.Equal(t, vals[i], loaded.Get(index))
+	}
+
+	// Writing a lazily opened bank maps any chunks not yet read
+	sb2, err := OpenLazy(path)
+	require.NoError(t, err)
+	defer sb2.Close()
+	var buf bytes.Buffer
+	_, err = sb2.WriteTo(&buf)
+	require.NoError(t, err)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.True(t, bytes.Equal(data, buf.Bytes()))
+}
+
+func TestOpenLazyChunkSize(t *testing.T) {
+	sb := New(1 << 12)
+	defer sb.Close()
+	var indices []int
+	for i := 0; i < 10000; i++ {
+		indices = append(indices, sb.Save(strconv.Itoa(i)))
+	}
+
+	path := filepath.Join(t.TempDir(), "bank")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	_, err = sb.WriteTo(f)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	loaded, err := OpenLazy(path)
+	require.NoError(t, err)
+	defer loaded.Close()
+	assert.Equal(t, 1<<12, loaded.EffectiveChunkSize())
+	for i, index := range indices {
+		assert.Equal(t, strconv.Itoa(i), loaded.Get(index))
+	}
+}
+
+func TestForEachOpenLazy(t *testing.T) {
+	sb := Stringbank{}
+	defer sb.Close()
+	var vals []string
+	for i := 0; i < 100000; i++ {
+		vals = append(vals, strconv.Itoa(i))
+	}
+	vals = append(vals, strings.Repeat("b", 3*defaultChunkSize), "after")
+	for _, v := range vals {
+		sb.Save(v)
+	}
+
+	path := filepath.Join(t.TempDir(), "bank")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	_, err = sb.WriteTo(f)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	loaded, err := OpenLazy(path)
+	require.NoError(t, err)
+	defer loaded.Close()
+	assert.Equal(t, sb.Used(), loaded.Used())
+	loaded.Save("new")
+	vals = append(vals, "new")
+	assert.Equal(t, len(vals), loaded.Len())
+	assert.Equal(t, sb.Used()+4, loaded.Used())
+
+	var i int
+	loaded.ForEach(func(index int, val string) bool {
+		assert.Equal(t, vals[i], val)
+		assert.Equal(t, val, loaded.Get(index))
+		i++
+		return true
+	})
+	assert.Equal(t, len(vals), i)
+}
+
+func TestOpenLazySetChunkSize(t *testing.T) {
+	// testdata/setchunksize.sbnk is written by the heap-based stringbank package with New(64) and
+	// SetChunkSize(4096), so its chunks are larger than the chunk size in its header
+	const path = "testdata/setchunksize.sbnk"
+	var vals []string
+	for i := 0; i < 2000; i++ {
+		v := strconv.Itoa(i)
+		if i%500 == 0 {
+			v = strings.Repeat(v, 100)
+		}
+		vals = append(vals, v)
+	}
+
+	sb, err := OpenLazy(path)
+	require.NoError(t, err)
+	defer sb.Close()
+	assert.Equal(t, 64, sb.EffectiveChunkSize())
+
+	var indices []int
+	sb.ForEach(func(index int, val string) bool {
+		indices = append(indices, index)
+		return true
+	})
+	require.Len(t, indices, len(vals))
+	// Fetch strings out of order so chunks are mapped by Get as well as ForEach
+	for i := len(vals) - 1; i >= 0; i-- {
+		assert.Equal(t, vals[i], sb.Get(indices[i]))
+	}
+	assert.Equal(t, len(vals), sb.Len())
+
+	// Strings saved after opening are placed after the file's chunks
+	index := sb.Save("new")
+	assert.Equal(t, "new", sb.Get(index))
+	large := sb.Save(strings.Repeat("x", 10000))
+	after := sb.Save("after")
+	assert.Equal(t, strings.Repeat("x", 10000), sb.Get(large))
+	assert.Equal(t, "after", sb.Get(after))
+	assert.Equal(t, vals[1999], sb.Get(indices[1999]))
+
+	var buf bytes.Buffer
+	sb2, err := OpenLazy(path)
+	require.NoError(t, err)
+	defer sb2.Close()
+	_, err = sb2.WriteTo(&buf)
+	require.NoError(t, err)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.True(t, bytes.Equal(data, buf.Bytes()))
+}
+
+func TestOpenLazySmallChunks(t *testing.T) {
+	// testdata/setchunksize.sbnk has a chunk size of 64 bytes, much smaller than a page
+	loaded, err := OpenLazy("testdata/setchunksize.sbnk")
+	require.NoError(t, err)
+	defer loaded.Close()
+	assert.Equal(t, 64, loaded.EffectiveChunkSize())
+
+	fileChunks := len(loaded.allocations)
+	var indices []int
+	for i := 0; i < 1000; i++ {
+		indices = append(indices, loaded.Save(strconv.Itoa(i)))
+	}
+	for i, index := range indices {
+		assert.Equal(t, strconv.Itoa(i), loaded.Get(index))
+	}
+
+	// The new chunks share pages rather than each taking one of their own
+	chunks := len(loaded.allocations) - fileChunks
+	pageSize := os.Getpagesize()
+	assert.True(t, len(loaded.regions) <= (chunks*64+pageSize-1)/pageSize, len(loaded.regions))
+	assert.Equal(t, chunks*64, loaded.Size())
+}
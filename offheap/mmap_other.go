@@ -0,0 +1,36 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package offheap
+
+import (
+	"io"
+	"os"
+)
+
+// On platforms without mmap, memory for the bank comes from ordinary Go slices. The strings then live on the Go
+// heap, but the bank still holds only a few large pointer-free allocations, so the GC has little work to do.
+
+// alloc returns size bytes of memory, with zero length and capacity size
+func alloc(size int) ([]byte, error) {
+	return make([]byte, 0, size), nil
+}
+
+// free releases memory returned by alloc. There is nothing to do, as the GC reclaims the memory
+func free(data []byte) error {
+	return nil
+}
+
+// mapFile reads length bytes of f starting at offset into memory
+func mapFile(f *os.File, offset int64, length int) ([]byte, error) {
+	data := make([]byte, length)
+	if _, err := f.ReadAt(data, offset); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return data, nil
+}
+
+// unmapFile releases memory returned by mapFile
+func unmapFile(data []byte) error {
+	return nil
+}
@@ -0,0 +1,42 @@
+package offheap
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlloc(t *testing.T) {
+	data, err := alloc(1 << 16)
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(data))
+	assert.Equal(t, 1<<16, cap(data))
+
+	data = data[:cap(data)]
+	for i := range data {
+		assert.Zero(t, data[i])
+		data[i] = byte(i)
+	}
+	assert.NoError(t, free(data[:0]))
+}
+
+func TestMapFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file")
+	contents := make([]byte, 3*os.Getpagesize())
+	for i := range contents {
+		contents[i] = byte(i)
+	}
+	require.NoError(t, os.WriteFile(path, contents, 0600))
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	offset := os.Getpagesize()
+	data, err := mapFile(f, int64(offset), 100)
+	require.NoError(t, err)
+	assert.Equal(t, contents[offset:offset+100], data[:100])
+	assert.NoError(t, unmapFile(data))
+}
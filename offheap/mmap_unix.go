@@ -0,0 +1,39 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package offheap
+
+import (
+	"os"
+	"syscall"
+)
+
+// alloc returns size bytes of memory allocated directly from the OS, outside the Go heap. The memory is returned
+// with zero length and capacity size
+func alloc(size int) ([]byte, error) {
+	data, err := syscall.Mmap(-1, 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+	if err != nil {
+		return nil, os.NewSyscallError("mmap", err)
+	}
+	return data[:0], nil
+}
+
+// free releases memory returned by alloc
+func free(data []byte) error {
+	return os.NewSyscallError("munmap", syscall.Munmap(data[:cap(data)]))
+}
+
+// mapFile maps length bytes of f starting at offset into memory for reading. offset must be a multiple of the
+// page size
+func mapFile(f *os.File, offset int64, length int) ([]byte, error) {
+	data, err := syscall.Mmap(int(f.Fd()), offset, length, syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, os.NewSyscallError("mmap", err)
+	}
+	return data, nil
+}
+
+// unmapFile releases memory returned by mapFile
+func unmapFile(data []byte) error {
+	return os.NewSyscallError("munmap", syscall.Munmap(data))
+}
@@ -0,0 +1,60 @@
+package offheap
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// The file format is shared with the heap-based stringbank package, so files written by either package can be
+// read by the other. A short header is followed by each chunk in turn. A chunk may be longer than chunkSize, either
+// because it holds a single large string or because the heap-based package was told to use larger chunks.
+//
+//	magic     "SBNK"
+//	version   1 byte
+//	chunkSize uvarint
+//	numChunks uvarint
+//
+// Each chunk is written as its used length (uvarint) followed by that many bytes of chunk data. The chunk data
+// is exactly what is held in memory, so indices remain valid after the bank is reloaded.
+const (
+	fileMagic   = "SBNK"
+	fileVersion = 1
+)
+
+// ErrBadMagic is returned when opening a file that does not start with the stringbank file header
+var ErrBadMagic = errors.New("offheap: file is not a serialized stringbank")
+
+// WriteTo writes the contents of the Stringbank to w. It returns the number of bytes written. The file can be
+// reopened with OpenLazy, and indices from this bank remain valid in the reopened bank.
+func (s *Stringbank) WriteTo(w io.Writer) (int64, error) {
+	cw := countingWriter{w: w}
+	bw := bufio.NewWriter(&cw)
+
+	var buf [binary.MaxVarintLen64]byte
+	bw.WriteString(fileMagic)
+	bw.WriteByte(fileVersion)
+	bw.Write(buf[:binary.PutUvarint(buf[:], uint64(s.chunkSize()))])
+	bw.Write(buf[:binary.PutUvarint(buf[:], uint64(len(s.allocations)))])
+	for i, chunk := range s.allocations {
+		if chunk == nil && s.lazy != nil && i < len(s.lazy.lengths) && s.lazy.lengths[i] > 0 {
+			chunk = s.mapChunk(i)
+		}
+		bw.Write(buf[:binary.PutUvarint(buf[:], uint64(len(chunk)))])
+		bw.Write(chunk)
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -1,48 +1,201 @@
 // Package offheap is an off-heap implementation of stringbank. Memory to back the strings is allocated
-// in chunks directly from the OS
+// in chunks directly from the OS with mmap. On platforms without mmap, such as Windows, the chunks are ordinary Go
+// slices instead, so the package behaves the same everywhere
 package offheap
 
 import (
+	"fmt"
+	"log"
 	"math/bits"
-	"reflect"
+	"os"
+	"runtime"
 	"unsafe"
-
-	"github.com/philpearl/mmap"
 )
 
 const stringbankSize = 1 << 18 // about 250k as a power of 2
 
+// defaultChunkSize is the size of each chunk we allocate unless another size is passed to New. It is
+// stringbankSize rounded up to a whole number of pages, as memory comes from the OS in pages and anything beyond
+// the end of a chunk in its last page would be wasted
+var defaultChunkSize = roundToPage(stringbankSize, os.Getpagesize())
+
+func roundToPage(size, pageSize int) int {
+	return (size + pageSize - 1) / pageSize * pageSize
+}
+
 // Stringbank is a place to put strings that never need to be deleted. Saving a string into the Stringbank
 // returns an integer offset for the string, so the string can be stored and referenced without bothering the
 // garbage collector. The offset can be exchanged for the original string via a call to Get
 type Stringbank struct {
-	current     []byte
+	// chunk is the size of each chunk. Zero means the default, defaultChunkSize
+	chunk   int
+	current []byte
+	// allocations holds each chunk of the bank, sliced to the length that has been used. A string too large for a
+	// chunk is given an allocation of its own. This is followed by nil entries so that it takes up as many chunks'
+	// worth of index space as its size. That way each index still maps directly to a chunk and offset
 	allocations [][]byte
+	// starts holds, for each entry in allocations, the entry where the allocation covering it begins. It is only
+	// set for a bank opened from a file written by the heap-based stringbank package after SetChunkSize was used to
+	// make chunks larger than chunk. Strings may then start beyond the first entry of their chunk
+	starts []int
+	// regions holds each block of memory allocated from the OS. A region may be divided into several chunks
+	regions [][]byte
+	// spare is the part of the most recent region not yet handed out as chunks
+	spare []byte
+	// growAfter is set by SetGrowth. Once this many regions have been allocated, each new region holds twice as
+	// many chunks as the last. Zero means each chunk is allocated separately
+	growAfter int
+	// regionChunks is the number of chunks in the most recent region
+	regionChunks int
+	// size is the total size of the allocations
+	size int
+	// count is the number of strings saved. For a lazily opened bank this excludes the strings in the file
+	count     int
+	leakCheck bool
+	// lazy is set if the Stringbank was opened with OpenLazy. The first chunks of the bank are then mapped from
+	// the file on demand
+	lazy *lazyFile
+}
+
+// New returns a Stringbank that allocates memory in chunks of chunkSize bytes, rounded up to a whole number of
+// pages. chunkSize must be a power of two, and New panics if it is not or if it is less than 16. A zero
+// Stringbank uses chunks of 256KB.
+func New(chunkSize int) *Stringbank {
+	if err := checkChunkSize(chunkSize); err != nil {
+		panic(err)
+	}
+	return &Stringbank{chunk: roundToPage(chunkSize, os.Getpagesize())}
+}
+
+// minChunkSize is the smallest chunk size allowed. A chunk must hold at least a full length prefix
+const minChunkSize = 16
+
+func checkChunkSize(chunkSize int) error {
+	if chunkSize < minChunkSize || chunkSize&(chunkSize-1) != 0 {
+		return fmt.Errorf("offheap: chunk size %d is not a power of two of at least %d", chunkSize, minChunkSize)
+	}
+	return nil
+}
+
+// chunkSize returns the size of each chunk of the bank
+func (s *Stringbank) chunkSize() int {
+	if s.chunk == 0 {
+		return defaultChunkSize
+	}
+	return s.chunk
+}
+
+// NewWithLeakCheck returns a Stringbank that logs a warning if it is garbage collected without Close having been
+// called. Forgetting to Close an offheap Stringbank otherwise leaks its memory silently.
+func NewWithLeakCheck() *Stringbank {
+	s := &Stringbank{leakCheck: true}
+	runtime.SetFinalizer(s, checkLeak)
+	return s
+}
+
+func checkLeak(s *Stringbank) {
+	if s.allocations != nil {
+		reportLeak(s)
+	}
+}
+
+// reportLeak is called when a leak-checked Stringbank is garbage collected without being closed
+var reportLeak = func(s *Stringbank) {
+	log.Printf("offheap: Stringbank garbage collected without Close being called. %d bytes leaked", s.Size())
 }
 
 // Close releases resources associated with the StringBank
 func (s *Stringbank) Close() error {
-	for _, allocation := range s.allocations {
-		if err := mmap.Free(*(*reflect.SliceHeader)(unsafe.Pointer(&allocation)), 1); err != nil {
+	if s.leakCheck {
+		runtime.SetFinalizer(s, nil)
+		s.leakCheck = false
+	}
+	for i, region := range s.regions {
+		if err := free(region); err != nil {
+			s.regions = s.regions[i:]
+			return err
+		}
+	}
+	s.regions = nil
+	s.spare = nil
+	s.regionChunks = 0
+	if s.lazy != nil {
+		if err := s.lazy.close(); err != nil {
 			return err
 		}
+		s.lazy = nil
 	}
 	s.allocations = nil
+	s.starts = nil
 	s.current = nil
+	s.size = 0
+	s.count = 0
 	return nil
 }
 
 // Size returns the approximate number of bytes in the string bank. The estimate includes currently unused and
 // wasted space
 func (s *Stringbank) Size() int {
-	return len(s.allocations) * stringbankSize
+	return s.size
+}
+
+// Used returns the number of bytes written to the bank, including length prefixes. Unlike Size it excludes the
+// unused space at the end of each chunk
+func (s *Stringbank) Used() int {
+	var used int
+	for i, data := range s.allocations {
+		if data == nil && s.lazy != nil && i < len(s.lazy.lengths) {
+			// This chunk is in the file but has not been mapped yet
+			used += s.lazy.lengths[i]
+			continue
+		}
+		used += len(data)
+	}
+	return used
+}
+
+// Len returns the number of strings saved in the bank. The first call on a lazily opened bank maps the file's
+// chunks to count the strings within them
+func (s *Stringbank) Len() int {
+	if s.lazy == nil {
+		return s.count
+	}
+	if s.lazy.count < 0 {
+		s.lazy.count = 0
+		for i, length := range s.lazy.lengths {
+			if length == 0 {
+				continue
+			}
+			data := s.allocations[i]
+			if data == nil {
+				data = s.mapChunk(i)
+			}
+			for offset := 0; offset < len(data); {
+				l, llen := readLength(data[offset:])
+				offset += llen + l
+				s.lazy.count++
+			}
+		}
+	}
+	return s.lazy.count + s.count
+}
+
+// EffectiveChunkSize returns the size of each chunk of memory allocated by the Stringbank. This is rounded up to a
+// multiple of the system page size, except for a bank opened by OpenLazy from a file with smaller chunks, which keeps
+// the file's chunk size so its indices remain valid. New chunks are then handed out from whole pages, several to a
+// page
+func (s *Stringbank) EffectiveChunkSize() int {
+	return s.chunkSize()
 }
 
 // Get converts an index to the original string
 func (s *Stringbank) Get(index int) string {
 	// read the length and string from the data
-	data := s.allocations[index/stringbankSize]
-	offset := index % stringbankSize
+	slot, offset := s.locate(index)
+	data := s.allocations[slot]
+	if data == nil && s.lazy != nil {
+		data = s.mapChunk(slot)
+	}
 	if l := data[offset]; l&0x80 == 0 {
 		b := data[offset+1 : offset+1+int(l)]
 		return *(*string)(unsafe.Pointer(&b))
@@ -53,6 +206,58 @@ func (s *Stringbank) Get(index int) string {
 	return *(*string)(unsafe.Pointer(&b))
 }
 
+// ForEach calls fn for each string in the Stringbank in the order they were saved, passing the index of the
+// string and its value. Iteration stops early if fn returns false. Chunks of a lazily opened Stringbank are mapped
+// as they are reached
+func (s *Stringbank) ForEach(fn func(index int, val string) bool) {
+	chunkSize := s.chunkSize()
+	for i, data := range s.allocations {
+		if data == nil {
+			if s.lazy == nil || i >= len(s.lazy.offsets) || s.lazy.lengths[i] == 0 {
+				// This is a placeholder after a large string
+				continue
+			}
+			data = s.mapChunk(i)
+		}
+		// Each allocation is sliced to the length used, so we stop before any unwritten bytes
+		for offset := 0; offset < len(data); {
+			l, llen := readLength(data[offset:])
+			b := data[offset+llen : offset+llen+l]
+			if !fn(i*chunkSize+offset, *(*string)(unsafe.Pointer(&b))) {
+				return
+			}
+			offset += llen + l
+		}
+	}
+}
+
+// locate returns the entry in allocations holding the string at index, and the offset of the string within it
+func (s *Stringbank) locate(index int) (slot, offset int) {
+	chunkSize := s.chunkSize()
+	slot, offset = index/chunkSize, index%chunkSize
+	if s.starts != nil {
+		start := s.starts[slot]
+		offset += (slot - start) * chunkSize
+		slot = start
+	}
+	return slot, offset
+}
+
+// extendStarts adds entries to starts for allocations added since it was last extended, if starts is in use. Each
+// nil entry is covered by the allocation before it
+func (s *Stringbank) extendStarts() {
+	if s.starts == nil {
+		return
+	}
+	for i := len(s.starts); i < len(s.allocations); i++ {
+		start := i
+		if s.allocations[i] == nil {
+			start = s.starts[i-1]
+		}
+		s.starts = append(s.starts, start)
+	}
+}
+
 // Save copies a string into the Stringbank, and returns the index of the string in the bank
 func (s *Stringbank) Save(tocopy string) int {
 	l := len(tocopy)
@@ -63,6 +268,7 @@ func (s *Stringbank) Save(tocopy string) int {
 		buf[0] = byte(l)
 		// write data
 		copy(buf[1:], tocopy)
+		s.count++
 		return offset
 	}
 	offset, buf := s.reserve(l + spaceForLength(l))
@@ -71,19 +277,94 @@ func (s *Stringbank) Save(tocopy string) int {
 
 	// Write the data
 	copy(buf[start:], tocopy)
+	s.count++
 	return offset
 }
 
 // reserve finds a contiguous space of length l that can be used for writing data
 func (s *Stringbank) reserve(l int) (index int, data []byte) {
+	chunkSize := s.chunkSize()
+	if l > chunkSize {
+		return s.reserveLarge(l)
+	}
 	if len(s.current)+l > cap(s.current) {
-		slice, _ := mmap.Alloc(1, stringbankSize)
-		s.current = *(*[]byte)(unsafe.Pointer(&slice))
-		s.allocations = append(s.allocations, s.current[0:stringbankSize])
+		s.current = s.newChunk()
+		s.allocations = append(s.allocations, s.current)
+		s.extendStarts()
+		s.size += chunkSize
 	}
 	offset := len(s.current)
 	s.current = s.current[:offset+l]
-	return (len(s.allocations)-1)*stringbankSize + offset, s.current[offset:]
+	s.allocations[len(s.allocations)-1] = s.current
+	return (len(s.allocations)-1)*chunkSize + offset, s.current[offset:]
+}
+
+// reserveLarge makes a dedicated allocation for data of length l, which is too large to fit in a chunk
+func (s *Stringbank) reserveLarge(l int) (index int, data []byte) {
+	size := roundToPage(l, os.Getpagesize())
+	data = mustAlloc(size)[:size]
+	s.regions = append(s.regions, data)
+	chunkSize := s.chunkSize()
+	index = len(s.allocations) * chunkSize
+	s.allocations = append(s.allocations, data[:l])
+	for n := chunkSize; n < l; n += chunkSize {
+		s.allocations = append(s.allocations, nil)
+	}
+	s.extendStarts()
+	s.size += size
+	// Start a new chunk for the next string, so strings stay in the order they were saved
+	s.current = nil
+	return index, data[:l]
+}
+
+// maxRegionChunks limits how large SetGrowth lets regions become
+const maxRegionChunks = 64
+
+// SetGrowth reduces the number of requests made to the OS for memory by a large bank. Once after requests have been
+// made, each new request is for twice as many chunks as the last, up to 64 chunks at a time. The chunks are handed
+// out one at a time as before, so indices and Size are unaffected, though memory is only returned to the OS when
+// the bank is closed. A value of zero, the default, allocates each chunk separately.
+func (s *Stringbank) SetGrowth(after int) {
+	s.growAfter = after
+}
+
+// newChunk returns an empty chunk, taking it from the spare part of the current region if there is one
+func (s *Stringbank) newChunk() []byte {
+	chunkSize := s.chunkSize()
+	if len(s.spare) == 0 {
+		n := 1
+		if s.growAfter > 0 && len(s.regions) >= s.growAfter {
+			n = s.regionChunks * 2
+			if n < 2 {
+				n = 2
+			} else if n > maxRegionChunks {
+				n = maxRegionChunks
+			}
+		}
+		// Memory comes from the OS in pages, so share a page between chunks smaller than one. Page sizes and chunk
+		// sizes are both powers of two, so a page holds a whole number of chunks
+		if perPage := os.Getpagesize() / chunkSize; n < perPage {
+			n = perPage
+		}
+		region := mustAlloc(n * chunkSize)
+		s.regions = append(s.regions, region)
+		s.regionChunks = n
+		s.spare = region[:n*chunkSize]
+	}
+	// Limit the capacity so the chunk can't run into the next one
+	chunk := s.spare[:0:chunkSize]
+	s.spare = s.spare[chunkSize:]
+	return chunk
+}
+
+// mustAlloc allocates size bytes of memory for the bank. Running out of memory is not something Save can report,
+// so it panics if the allocation fails
+func mustAlloc(size int) []byte {
+	data, err := alloc(size)
+	if err != nil {
+		panic(fmt.Sprintf("offheap: failed to allocate %d bytes: %v", size, err))
+	}
+	return data
 }
 
 func spaceForLength(len int) int {
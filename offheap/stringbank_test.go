@@ -2,8 +2,10 @@ package offheap
 
 import (
 	"fmt"
+	"os"
 	"runtime"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -37,12 +39,252 @@ func TestCloseAbuse(t *testing.T) {
 	assert.Equal(t, "hello", sb.Get(s1))
 }
 
+func TestLeakCheck(t *testing.T) {
+	leaked := make(chan struct{}, 1)
+	oldReportLeak := reportLeak
+	defer func() { reportLeak = oldReportLeak }()
+	reportLeak = func(s *Stringbank) {
+		s.Close()
+		leaked <- struct{}{}
+	}
+
+	func() {
+		sb := NewWithLeakCheck()
+		sb.Save("hello")
+	}()
+
+	for i := 0; i < 100; i++ {
+		runtime.GC()
+		select {
+		case <-leaked:
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	t.Fatal("leak was not reported")
+}
+
+func TestLeakCheckClosed(t *testing.T) {
+	leaked := make(chan struct{}, 1)
+	oldReportLeak := reportLeak
+	defer func() { reportLeak = oldReportLeak }()
+	reportLeak = func(s *Stringbank) {
+		leaked <- struct{}{}
+	}
+
+	func() {
+		sb := NewWithLeakCheck()
+		sb.Save("hello")
+		assert.NoError(t, sb.Close())
+	}()
+
+	for i := 0; i < 10; i++ {
+		runtime.GC()
+		select {
+		case <-leaked:
+			t.Fatal("leak reported for closed bank")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestLargeStrings(t *testing.T) {
+	// The length prefix for these strings takes 3 bytes
+	exact := strings.Repeat("e", defaultChunkSize-3)
+	over := strings.Repeat("o", defaultChunkSize-2)
+	huge := strings.Repeat("h", 3*defaultChunkSize+17)
+
+	sb := Stringbank{}
+	defer sb.Close()
+	vals := []string{"hello", exact, "a", over, "b", huge, "c", huge, over, exact, exact, "d"}
+	var indices []int
+	for _, v := range vals {
+		indices = append(indices, sb.Save(v))
+	}
+
+	for i, index := range indices {
+		assert.Equal(t, vals[i], sb.Get(index))
+	}
+
+	// The exact size string fills a chunk by itself
+	assert.Equal(t, indices[1]+defaultChunkSize, indices[2])
+}
+
+func TestLargeStringSize(t *testing.T) {
+	sb := Stringbank{}
+	defer sb.Close()
+	sb.Save("hello")
+	sb.Save(strings.Repeat("o", defaultChunkSize))
+	// Large allocations are rounded up to a whole number of pages
+	large := roundToPage(defaultChunkSize+3, os.Getpagesize())
+	assert.Equal(t, defaultChunkSize+large, sb.Size())
+	sb.Save("hello")
+	assert.Equal(t, 2*defaultChunkSize+large, sb.Size())
+}
+
 func TestStringbankSize(t *testing.T) {
 	sb := Stringbank{}
 	defer sb.Close()
 	assert.Zero(t, sb.Size())
 	sb.Save("hello")
-	assert.Equal(t, stringbankSize, sb.Size())
+	assert.Equal(t, sb.EffectiveChunkSize(), sb.Size())
+}
+
+func TestNew(t *testing.T) {
+	pageSize := os.Getpagesize()
+	sb := New(16)
+	defer sb.Close()
+	assert.Equal(t, pageSize, sb.EffectiveChunkSize())
+
+	big := New(1 << 22)
+	defer big.Close()
+	assert.Equal(t, 1<<22, big.EffectiveChunkSize())
+
+	vals := []string{"hello", strings.Repeat("a", pageSize), "goodbye", strings.Repeat("b", 1<<12-3)}
+	for _, sb := range []*Stringbank{sb, big} {
+		var indices []int
+		for _, v := range vals {
+			indices = append(indices, sb.Save(v))
+		}
+		for i, index := range indices {
+			assert.Equal(t, vals[i], sb.Get(index))
+		}
+	}
+
+	assert.Panics(t, func() { New(0) })
+	assert.Panics(t, func() { New(8) })
+	assert.Panics(t, func() { New(1000) })
+}
+
+func TestEffectiveChunkSize(t *testing.T) {
+	sb := Stringbank{}
+	size := sb.EffectiveChunkSize()
+	assert.Zero(t, size%os.Getpagesize())
+	assert.True(t, size >= stringbankSize)
+}
+
+func TestRoundToPage(t *testing.T) {
+	assert.Equal(t, 1<<18, roundToPage(1<<18, 4096))
+	assert.Equal(t, 1<<18, roundToPage(1<<18, 1<<16))
+	assert.Equal(t, 1<<20, roundToPage(1<<18, 1<<20))
+	assert.Equal(t, 3*4096, roundToPage(2*4096+1, 4096))
+}
+
+func TestUsed(t *testing.T) {
+	sb := New(1 << 12)
+	defer sb.Close()
+	assert.Zero(t, sb.Used())
+	assert.Zero(t, sb.Len())
+
+	sb.Save("hello")
+	sb.Save("")
+	assert.Equal(t, 7, sb.Used())
+	assert.Equal(t, 2, sb.Len())
+
+	// This crosses into a new chunk, leaving the end of the first unused
+	sb.Save(strings.Repeat("a", sb.EffectiveChunkSize()-6))
+	assert.Equal(t, 7+2+sb.EffectiveChunkSize()-6, sb.Used())
+	assert.Equal(t, 2*sb.EffectiveChunkSize(), sb.Size())
+	assert.Equal(t, 3, sb.Len())
+
+	sb.Close()
+	assert.Zero(t, sb.Used())
+	assert.Zero(t, sb.Len())
+}
+
+func TestForEach(t *testing.T) {
+	sb := New(1 << 12)
+	defer sb.Close()
+	var indices []int
+	var vals []string
+	for i := 0; i < 10000; i++ {
+		v := strconv.Itoa(i)
+		switch i % 1000 {
+		case 0:
+			v = strings.Repeat(v, 5000)
+		case 1:
+			v = ""
+		case 2:
+			v = strings.Repeat(v, 100)
+		}
+		indices = append(indices, sb.Save(v))
+		vals = append(vals, v)
+	}
+
+	var i int
+	sb.ForEach(func(index int, val string) bool {
+		assert.Equal(t, indices[i], index)
+		assert.Equal(t, vals[i], val)
+		i++
+		return true
+	})
+	assert.Equal(t, len(indices), i)
+
+	i = 0
+	sb.ForEach(func(index int, val string) bool {
+		i++
+		return false
+	})
+	assert.Equal(t, 1, i)
+
+	(&Stringbank{}).ForEach(func(index int, val string) bool {
+		t.Fatal("empty bank should have no strings")
+		return true
+	})
+}
+
+func TestSetGrowth(t *testing.T) {
+	sb := New(1 << 12)
+	defer sb.Close()
+	sb.SetGrowth(4)
+
+	var indices []int
+	var vals []string
+	for i := 0; i < 100000; i++ {
+		v := strconv.Itoa(i)
+		if i%10000 == 0 {
+			v = strings.Repeat("x", 10000)
+		}
+		indices = append(indices, sb.Save(v))
+		vals = append(vals, v)
+	}
+	for i, index := range indices {
+		assert.Equal(t, vals[i], sb.Get(index))
+	}
+
+	// Regions grow once 4 have been allocated, so there are far fewer regions than chunks
+	assert.True(t, len(sb.regions) < 30, len(sb.regions))
+	var chunks int
+	for _, data := range sb.allocations {
+		if data != nil {
+			chunks++
+		}
+	}
+	assert.True(t, chunks > 150, chunks)
+	assert.Equal(t, (chunks-10)*sb.EffectiveChunkSize()+10*roundToPage(10002, os.Getpagesize()), sb.Size())
+
+	assert.NoError(t, sb.Close())
+	assert.Zero(t, sb.Size())
+	assert.Nil(t, sb.regions)
+}
+
+func BenchmarkSetGrowth(b *testing.B) {
+	for _, after := range []int{0, 4} {
+		b.Run(fmt.Sprintf("after=%d", after), func(b *testing.B) {
+			b.ReportAllocs()
+			var regions int
+			for i := 0; i < b.N; i++ {
+				sb := Stringbank{}
+				sb.SetGrowth(after)
+				for j := 0; j < 1000000; j++ {
+					sb.Save("a moderately long string to fill the bank")
+				}
+				regions += len(sb.regions)
+				sb.Close()
+			}
+			b.ReportMetric(float64(regions)/float64(b.N), "mmaps/op")
+		})
+	}
 }
 
 func TestLengths(t *testing.T) {
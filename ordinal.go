@@ -0,0 +1,53 @@
+package stringbank
+
+// ordinalStride controls how many strings are saved between each entry in the ordinals table. Finding a string by
+// ordinal steps through at most ordinalStride-1 strings from the nearest entry
+const ordinalStride = 64
+
+// GetOrdinal returns the string that was saved at the given zero-based position in the order of saves, along
+// with its index. It panics if ordinal is out of range
+func (s *Stringbank) GetOrdinal(ordinal int) (string, int) {
+	if ordinal < 0 || ordinal >= s.count {
+		panic("stringbank: ordinal out of range")
+	}
+	index := s.ordinals[ordinal/ordinalStride]
+	for i := ordinal % ordinalStride; i > 0; i-- {
+		index = s.next(index)
+	}
+	return s.Get(index), index
+}
+
+// BankView presents the strings in a Stringbank as a read-only sequence, ordered by when they were saved, in the
+// manner of a []string. Unlike a real []string it holds no pointers to the strings, so costs the GC nothing
+type BankView struct {
+	bank *Stringbank
+}
+
+// View returns a BankView of the bank. The view reflects strings saved after it was created
+func (s *Stringbank) View() BankView {
+	return BankView{bank: s}
+}
+
+// At returns the string at the given zero-based position in the order of saves. It panics if ordinal is out of
+// range
+func (v BankView) At(ordinal int) string {
+	val, _ := v.bank.GetOrdinal(ordinal)
+	return val
+}
+
+// Len returns the number of strings in the view
+func (v BankView) Len() int {
+	return v.bank.Len()
+}
+
+// next returns the index of the string saved after the string at index
+func (s *Stringbank) next(index int) int {
+	chunk, offset := s.locate(index)
+	l, llen := readLength(s.allocations[chunk][offset:])
+	offset += llen + l
+	for offset >= len(s.allocations[chunk]) {
+		chunk++
+		offset = 0
+	}
+	return chunk*s.chunkSize() + offset
+}
@@ -0,0 +1,66 @@
+package stringbank
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetOrdinal(t *testing.T) {
+	sb := Stringbank{}
+	var indices []int
+	for i := 0; i < 100000; i++ {
+		indices = append(indices, sb.Save(strconv.Itoa(i)))
+	}
+
+	for _, ordinal := range []int{0, 1, 63, 64, 65, 1000, 99998, 99999} {
+		val, index := sb.GetOrdinal(ordinal)
+		assert.Equal(t, strconv.Itoa(ordinal), val)
+		assert.Equal(t, indices[ordinal], index)
+	}
+
+	assert.Panics(t, func() { sb.GetOrdinal(-1) })
+	assert.Panics(t, func() { sb.GetOrdinal(100000) })
+}
+
+func TestGetOrdinalAllOrdinals(t *testing.T) {
+	sb := Stringbank{}
+	var indices []int
+	for i := 0; i < 300; i++ {
+		indices = append(indices, sb.Save(strconv.Itoa(i)))
+	}
+
+	for ordinal, expIndex := range indices {
+		val, index := sb.GetOrdinal(ordinal)
+		assert.Equal(t, strconv.Itoa(ordinal), val)
+		assert.Equal(t, expIndex, index)
+	}
+}
+
+func TestView(t *testing.T) {
+	sb := New(1 << 10)
+	v := sb.View()
+	assert.Equal(t, 0, v.Len())
+
+	for i := 0; i < 1000; i++ {
+		val := strconv.Itoa(i)
+		if i%100 == 0 {
+			val = strings.Repeat(val, 500)
+		}
+		sb.Save(val)
+	}
+	assert.Equal(t, 1000, v.Len())
+
+	var ordinal int
+	sb.ForEach(func(index int, val string) bool {
+		assert.Equal(t, val, v.At(ordinal))
+		ordinal++
+		return true
+	})
+	assert.Equal(t, v.Len(), ordinal)
+
+	assert.Panics(t, func() { v.At(v.Len()) })
+	assert.Panics(t, func() { v.At(-1) })
+}
@@ -0,0 +1,52 @@
+package stringbank
+
+// OverlapWith counts the distinct strings that appear in both s and other, and the number of distinct strings in
+// the two banks combined. common/total is the Jaccard similarity of the two banks, and total is the number of
+// strings there would be in a deduplicated merge of the two.
+func (s *Stringbank) OverlapWith(other *Stringbank) (common int, total int) {
+	// These tables refer to the strings in place, so nothing is copied
+	var mine, theirs internTable
+	s.ForEachBytes(func(index int, b []byte) bool {
+		mine.add(s, index)
+		return true
+	})
+	other.ForEachBytes(func(index int, b []byte) bool {
+		if theirs.add(other, index) && mine.contains(s, other.Get(index)) {
+			common++
+		}
+		return true
+	})
+	return common, mine.count + theirs.count - common
+}
+
+// OnlyIn calls fn for each string in s that does not appear in other, in the order the strings were saved in s. A
+// string saved more than once in s is passed to fn each time. The table of other's strings refers to them in place,
+// so nothing is copied, and no slice of results is built.
+func (s *Stringbank) OnlyIn(other *Stringbank, fn func(value string)) {
+	var theirs internTable
+	other.ForEachBytes(func(index int, b []byte) bool {
+		theirs.add(other, index)
+		return true
+	})
+	s.ForEach(func(index int, val string) bool {
+		if !theirs.contains(other, val) {
+			fn(val)
+		}
+		return true
+	})
+}
+
+// Fingerprints returns a hash of each string in the bank, in the order the strings were saved. Two banks can
+// exchange fingerprints to find strings they probably share without exchanging the strings themselves. hasher
+// must not retain the bytes it is passed. If hasher is nil the 64-bit FNV-1a hash is used.
+func (s *Stringbank) Fingerprints(hasher func([]byte) uint64) []uint64 {
+	if hasher == nil {
+		hasher = fnv1a
+	}
+	fingerprints := make([]uint64, 0, s.Len())
+	s.ForEachBytes(func(index int, b []byte) bool {
+		fingerprints = append(fingerprints, hasher(b))
+		return true
+	})
+	return fingerprints
+}
@@ -0,0 +1,90 @@
+package stringbank
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOverlapWith(t *testing.T) {
+	a := Stringbank{}
+	b := Stringbank{}
+	for i := 0; i < 1000; i++ {
+		a.Save(strconv.Itoa(i))
+		// Duplicates within a bank count once
+		a.Save(strconv.Itoa(i))
+	}
+	for i := 500; i < 2000; i++ {
+		b.Save(strconv.Itoa(i))
+	}
+
+	common, total := a.OverlapWith(&b)
+	assert.Equal(t, 500, common)
+	assert.Equal(t, 2000, total)
+
+	common, total = b.OverlapWith(&a)
+	assert.Equal(t, 500, common)
+	assert.Equal(t, 2000, total)
+
+	common, total = a.OverlapWith(&Stringbank{})
+	assert.Equal(t, 0, common)
+	assert.Equal(t, 1000, total)
+
+	common, total = a.OverlapWith(&a)
+	assert.Equal(t, 1000, common)
+	assert.Equal(t, 1000, total)
+}
+
+func TestOnlyIn(t *testing.T) {
+	a := Stringbank{}
+	b := New(1 << 10)
+	for _, v := range []string{"apple", "banana", "", "cherry", "apple", "date"} {
+		a.Save(v)
+	}
+	for i := 0; i < 1000; i++ {
+		b.Save(strconv.Itoa(i))
+	}
+	b.Save("banana")
+	b.Save("")
+
+	var only []string
+	a.OnlyIn(b, func(value string) {
+		only = append(only, value)
+	})
+	assert.Equal(t, []string{"apple", "cherry", "apple", "date"}, only)
+
+	only = nil
+	b.OnlyIn(&a, func(value string) {
+		only = append(only, value)
+	})
+	assert.Len(t, only, 1000)
+
+	a.OnlyIn(&a, func(value string) {
+		t.Errorf("%q is in a", value)
+	})
+}
+
+func TestFingerprints(t *testing.T) {
+	a := Stringbank{}
+	b := New(1 << 10)
+	for _, v := range []string{"apple", "banana", "", "cherry"} {
+		a.Save(v)
+	}
+	for _, v := range []string{"cherry", "date", "apple", ""} {
+		b.Save(v)
+	}
+
+	fa := a.Fingerprints(nil)
+	fb := b.Fingerprints(nil)
+	assert.Len(t, fa, 4)
+	assert.Len(t, fb, 4)
+	assert.Equal(t, fa[0], fb[2])
+	assert.Equal(t, fa[2], fb[3])
+	assert.Equal(t, fa[3], fb[0])
+	assert.NotEqual(t, fa[1], fb[1])
+	assert.NotEqual(t, fa[0], fa[1])
+
+	lengths := a.Fingerprints(func(b []byte) uint64 { return uint64(len(b)) })
+	assert.Equal(t, []uint64{5, 6, 0, 6}, lengths)
+}
@@ -0,0 +1,255 @@
+package stringbank
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// The serialized form of a Stringbank is a short header followed by each chunk in turn.
+//
+//	magic     "SBNK"
+//	version   1 byte
+//	chunkSize uvarint
+//	numChunks uvarint
+//
+// Each chunk is written as its used length (uvarint) followed by that many bytes of chunk data. The chunk data
+// is exactly what is held in memory, so indices remain valid after the bank is reloaded.
+const (
+	fileMagic   = "SBNK"
+	fileVersion = 1
+)
+
+// ErrBadMagic is returned when reading data that does not start with the expected stringbank file or log header
+var ErrBadMagic = errors.New("stringbank: data is not a serialized stringbank")
+
+// WriteTo writes the contents of the Stringbank to w. It returns the number of bytes written. The bank can be
+// reloaded with ReadFrom, and indices from this bank remain valid in the reloaded bank. Only the strings are
+// written: interning, pins, sort keys and any save log are not preserved.
+func (s *Stringbank) WriteTo(w io.Writer) (int64, error) {
+	cw := countingWriter{w: w}
+	bw := bufio.NewWriter(&cw)
+
+	var buf [binary.MaxVarintLen64]byte
+	bw.WriteString(fileMagic)
+	bw.WriteByte(fileVersion)
+	bw.Write(buf[:binary.PutUvarint(buf[:], uint64(s.chunkSize()))])
+	bw.Write(buf[:binary.PutUvarint(buf[:], uint64(len(s.allocations)))])
+	for _, chunk := range s.allocations {
+		bw.Write(buf[:binary.PutUvarint(buf[:], uint64(len(chunk)))])
+		bw.Write(chunk)
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// ReadFrom reads a Stringbank written by WriteTo
+func ReadFrom(r io.Reader) (*Stringbank, error) {
+	br := bufio.NewReader(r)
+	h, err := readHeader(br)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkChunkSize(h.chunkSize); err != nil {
+		return nil, err
+	}
+
+	s := &Stringbank{chunk: h.chunkSize}
+	var spans bool
+	for i := 0; i < h.numChunks; i++ {
+		used, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, noEOF(err)
+		}
+		chunk, err := readChunk(br, used)
+		if err != nil {
+			return nil, err
+		}
+		s.allocations = append(s.allocations, chunk)
+		s.grow(len(chunk))
+		if len(chunk) > s.chunkSize() {
+			// Strings may start beyond the first chunk's worth of this allocation
+			spans = true
+		}
+	}
+	if spans {
+		s.buildStarts()
+	}
+	if err := s.recount(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// maxChunkAlloc is the largest chunk ReadFrom allocates in one go. Larger chunks are read into a growing buffer, so a
+// corrupt length can't make us allocate far more memory than the data that is actually there
+const maxChunkAlloc = 1 << 20
+
+// readChunk reads a chunk of length used
+func readChunk(r io.Reader, used uint64) ([]byte, error) {
+	if used == 0 {
+		return nil, nil
+	}
+	if used > uint64(maxInt) {
+		return nil, fmt.Errorf("stringbank: chunk length %d too large", used)
+	}
+	if used <= maxChunkAlloc {
+		chunk := make([]byte, used)
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			return nil, noEOF(err)
+		}
+		return chunk, nil
+	}
+	var buf bytes.Buffer
+	buf.Grow(maxChunkAlloc)
+	if _, err := io.CopyN(&buf, r, int64(used)); err != nil {
+		return nil, noEOF(err)
+	}
+	// Keep the capacity to the length, as it would be for a smaller chunk
+	return buf.Bytes()[:used:used], nil
+}
+
+// recount walks the strings in the bank to rebuild the counts and the ordinal table. It checks the length prefixes
+// are consistent with the chunks as it goes, so can be used to validate data read from elsewhere
+func (s *Stringbank) recount() error {
+	s.count, s.dataBytes, s.maxPrefixWidth, s.ordinals = 0, 0, 0, nil
+	chunkSize := s.chunkSize()
+	for i, chunk := range s.allocations {
+		for offset := 0; offset < len(chunk); {
+			// Length prefixes are uvarints, and binary.Uvarint tells us if one runs off the end of the chunk
+			ul, llen := binary.Uvarint(chunk[offset:])
+			l := int(ul)
+			if llen <= 0 || l < 0 || l > len(chunk)-offset-llen {
+				return fmt.Errorf("stringbank: corrupt data in chunk %d at offset %d", i, offset)
+			}
+			if s.count%ordinalStride == 0 {
+				s.ordinals = append(s.ordinals, i*chunkSize+offset)
+			}
+			s.count++
+			s.dataBytes += l
+			if llen > s.maxPrefixWidth {
+				s.maxPrefixWidth = llen
+			}
+			offset += llen + l
+		}
+	}
+	return nil
+}
+
+// StatFile reports the number of entries and the number of bytes of data (including length prefixes) held in
+// the serialized Stringbank at path, along with the format version of the file. The strings themselves are not
+// loaded, but their length prefixes are checked against the chunks, so a corrupt file returns an error.
+func StatFile(path string) (entries int, bytes int64, version int, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	h, err := readHeader(r)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	for i := 0; i < h.numChunks; i++ {
+		used, err := binary.ReadUvarint(r)
+		if err != nil {
+			return 0, 0, 0, noEOF(err)
+		}
+		if used > uint64(maxInt) {
+			return 0, 0, 0, fmt.Errorf("stringbank: chunk length %d too large", used)
+		}
+		bytes += int64(used)
+		for remaining := int(used); remaining > 0; {
+			l, llen, err := readLengthFrom(r)
+			if err != nil {
+				return 0, 0, 0, noEOF(err)
+			}
+			// Each string must lie within the chunk, so the walk ends exactly at the chunk's end
+			if l < 0 || l > remaining-llen {
+				return 0, 0, 0, fmt.Errorf("stringbank: corrupt data in chunk %d at offset %d", i, int(used)-remaining)
+			}
+			if _, err := r.Discard(l); err != nil {
+				return 0, 0, 0, noEOF(err)
+			}
+			remaining -= llen + l
+			entries++
+		}
+	}
+
+	return entries, bytes, int(h.version), nil
+}
+
+type fileHeader struct {
+	version   byte
+	chunkSize int
+	numChunks int
+}
+
+func readHeader(r *bufio.Reader) (h fileHeader, err error) {
+	var magic [len(fileMagic)]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return h, ErrBadMagic
+		}
+		return h, err
+	}
+	if string(magic[:]) != fileMagic {
+		return h, ErrBadMagic
+	}
+	if h.version, err = r.ReadByte(); err != nil {
+		return h, noEOF(err)
+	}
+	if h.version != fileVersion {
+		return h, fmt.Errorf("stringbank: unsupported file version %d", h.version)
+	}
+	chunkSize, err := binary.ReadUvarint(r)
+	if err != nil {
+		return h, noEOF(err)
+	}
+	numChunks, err := binary.ReadUvarint(r)
+	if err != nil {
+		return h, noEOF(err)
+	}
+	h.chunkSize, h.numChunks = int(chunkSize), int(numChunks)
+	return h, nil
+}
+
+// readLengthFrom reads a length prefix from r, returning the length and the number of bytes the prefix occupied
+func readLengthFrom(r io.ByteReader) (int, int, error) {
+	total := 0
+	for i := uint(0); ; i++ {
+		val, err := r.ReadByte()
+		if err != nil {
+			return 0, 0, err
+		}
+		total += int(val&0x7F) << (7 * i)
+		if val&0x80 == 0 {
+			return total, int(i + 1), nil
+		}
+	}
+}
+
+// noEOF converts io.EOF to io.ErrUnexpectedEOF. Running out of data part way through a file means it is truncated
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
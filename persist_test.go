@@ -0,0 +1,204 @@
+package stringbank
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeBankFile(t *testing.T, sb *Stringbank) string {
+	path := filepath.Join(t.TempDir(), "bank")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+	_, err = sb.WriteTo(f)
+	require.NoError(t, err)
+	return path
+}
+
+func TestStatFile(t *testing.T) {
+	sb := Stringbank{}
+	var used int64
+	for i := 0; i < 100000; i++ {
+		v := strconv.Itoa(i)
+		sb.Save(v)
+		used += int64(len(v) + 1)
+	}
+	sb.Save("")
+	sb.Save(strings.Repeat("a", 300))
+	used += 1 + 300 + 2
+
+	entries, bytes, version, err := StatFile(writeBankFile(t, &sb))
+	require.NoError(t, err)
+	assert.Equal(t, 100002, entries)
+	assert.Equal(t, used, bytes)
+	assert.Equal(t, fileVersion, version)
+}
+
+func TestStatFileEmpty(t *testing.T) {
+	entries, bytes, _, err := StatFile(writeBankFile(t, &Stringbank{}))
+	require.NoError(t, err)
+	assert.Zero(t, entries)
+	assert.Zero(t, bytes)
+}
+
+func TestStatFileBadMagic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bank")
+	require.NoError(t, os.WriteFile(path, []byte("not a bank"), 0600))
+
+	_, _, _, err := StatFile(path)
+	assert.Equal(t, ErrBadMagic, err)
+}
+
+func TestStatFileCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bank")
+	header := fileMagic + "\x01\x80\x80\x10\x01"
+	for data, exp := range map[string]string{
+		header + "\xff\xff\xff\xff\xff\xff\xff\xff\xff\x01": "stringbank: chunk length 18446744073709551615 too large",
+		// The string runs past the end of the chunk
+		header + "\x03\x05hello":      "stringbank: corrupt data in chunk 0 at offset 0",
+		header + "\x07\x01a\x05hello": "stringbank: corrupt data in chunk 0 at offset 2",
+	} {
+		require.NoError(t, os.WriteFile(path, []byte(data), 0600))
+		_, _, _, err := StatFile(path)
+		assert.EqualError(t, err, exp)
+	}
+
+	require.NoError(t, os.WriteFile(path, []byte(header+"\xff\xff\xff\xff\xff\xff\xff\xff\x7f"), 0600))
+	_, _, _, err := StatFile(path)
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+}
+
+func TestReadFrom(t *testing.T) {
+	sb := Stringbank{}
+	var indices []int
+	var vals []string
+	for i := 0; i < 100000; i++ {
+		vals = append(vals, strconv.Itoa(i))
+	}
+	vals = append(vals, "", strings.Repeat("a", 300), strings.Repeat("b", 3*stringbankSize), "after")
+	for _, v := range vals {
+		indices = append(indices, sb.Save(v))
+	}
+
+	var buf bytes.Buffer
+	n, err := sb.WriteTo(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, int64(buf.Len()), n)
+
+	loaded, err := ReadFrom(&buf)
+	require.NoError(t, err)
+	for i, index := range indices {
+		assert.Equal(t, vals[i], loaded.Get(index))
+	}
+	assert.Equal(t, sb.Len(), loaded.Len())
+	assert.Equal(t, sb.DataBytes(), loaded.DataBytes())
+	assert.Equal(t, sb.MaxPrefixWidth(), loaded.MaxPrefixWidth())
+	_, index := loaded.GetOrdinal(100001)
+	assert.Equal(t, indices[100001], index)
+
+	// The reloaded bank can be added to
+	s1 := loaded.Save("hello")
+	assert.Equal(t, "hello", loaded.Get(s1))
+	assert.Equal(t, "after", loaded.Get(indices[len(indices)-1]))
+}
+
+func TestReadFromEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	_, err := (&Stringbank{}).WriteTo(&buf)
+	require.NoError(t, err)
+
+	loaded, err := ReadFrom(&buf)
+	require.NoError(t, err)
+	assert.Zero(t, loaded.Len())
+	s1 := loaded.Save("hello")
+	assert.Equal(t, "hello", loaded.Get(s1))
+}
+
+func TestReadFromCorrupt(t *testing.T) {
+	sb := Stringbank{}
+	sb.Save("hello")
+	sb.Save("goodbye")
+	var buf bytes.Buffer
+	_, err := sb.WriteTo(&buf)
+	require.NoError(t, err)
+	data := buf.Bytes()
+
+	_, err = ReadFrom(bytes.NewReader(data[:len(data)-1]))
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+
+	_, err = ReadFrom(strings.NewReader("SBLG"))
+	assert.Equal(t, ErrBadMagic, err)
+
+	bad := append([]byte(nil), data...)
+	bad[len(fileMagic)] = 2
+	_, err = ReadFrom(bytes.NewReader(bad))
+	assert.EqualError(t, err, "stringbank: unsupported file version 2")
+
+	// Make the length of "goodbye" run past the end of the chunk
+	bad = append([]byte(nil), data...)
+	bad[len(bad)-8] = 8
+	_, err = ReadFrom(bytes.NewReader(bad))
+	assert.EqualError(t, err, "stringbank: corrupt data in chunk 0 at offset 6")
+
+	// A huge chunk length must not cause a huge allocation
+	header := fileMagic + "\x01\x80\x80\x10\x01"
+	_, err = ReadFrom(strings.NewReader(header + "\xff\xff\xff\xff\xff\xff\xff\xff\x7f"))
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+	_, err = ReadFrom(strings.NewReader(header + "\xff\xff\xff\xff\xff\xff\xff\xff\xff\x01"))
+	assert.EqualError(t, err, "stringbank: chunk length 18446744073709551615 too large")
+}
+
+func TestReadFromLargeChunk(t *testing.T) {
+	sb := Stringbank{}
+	indices := []int{sb.Save("hello"), sb.Save(strings.Repeat("a", 2*maxChunkAlloc)), sb.Save("after")}
+	var buf bytes.Buffer
+	_, err := sb.WriteTo(&buf)
+	require.NoError(t, err)
+
+	loaded, err := ReadFrom(&buf)
+	require.NoError(t, err)
+	for _, index := range indices {
+		assert.Equal(t, sb.Get(index), loaded.Get(index))
+	}
+	for _, chunk := range loaded.allocations {
+		assert.Equal(t, len(chunk), cap(chunk))
+	}
+}
+
+var update = flag.Bool("update", false, "update golden files")
+
+// spanningGolden is a file written by a bank whose chunks are larger than the chunk size in its header. The offheap
+// package's tests check it can read it
+const spanningGolden = "offheap/testdata/setchunksize.sbnk"
+
+func TestWriteToSetChunkSizeGolden(t *testing.T) {
+	// The offheap package's tests expect exactly these strings
+	sb := New(64)
+	sb.SetChunkSize(4096)
+	for i := 0; i < 2000; i++ {
+		v := strconv.Itoa(i)
+		if i%500 == 0 {
+			v = strings.Repeat(v, 100)
+		}
+		sb.Save(v)
+	}
+	var buf bytes.Buffer
+	_, err := sb.WriteTo(&buf)
+	require.NoError(t, err)
+
+	if *update {
+		require.NoError(t, os.WriteFile(spanningGolden, buf.Bytes(), 0644))
+	}
+	golden, err := os.ReadFile(spanningGolden)
+	require.NoError(t, err)
+	assert.True(t, bytes.Equal(golden, buf.Bytes()), "run go test -update to regenerate %s", spanningGolden)
+}
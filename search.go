@@ -0,0 +1,57 @@
+package stringbank
+
+import (
+	"sort"
+	"strings"
+)
+
+// Compare compares the string at index with val. The result is 0 if they are equal, -1 if the string at index
+// sorts before val and +1 if it sorts after
+func (s *Stringbank) Compare(index int, val string) int {
+	return strings.Compare(s.Get(index), val)
+}
+
+// HasPrefix returns true if the string at index begins with prefix
+func (s *Stringbank) HasPrefix(index int, prefix string) bool {
+	return strings.HasPrefix(s.Get(index), prefix)
+}
+
+// SortedIndices returns the indices of all the strings in the Stringbank, sorted by the strings' values
+func (s *Stringbank) SortedIndices() []int {
+	indices := make([]int, 0, s.count)
+	s.ForEachBytes(func(index int, b []byte) bool {
+		indices = append(indices, index)
+		return true
+	})
+	sort.Slice(indices, func(i, j int) bool {
+		return s.Get(indices[i]) < s.Get(indices[j])
+	})
+	return indices
+}
+
+// SearchPrefix uses binary search to find target in indices, which must be sorted by the values of the strings
+// they refer to, for example by SortedIndices. It returns the position in indices of the first string that is
+// not less than target. This is where target would be inserted, and if any strings start with target they
+// begin at this position.
+func (s *Stringbank) SearchPrefix(indices []int, target string) int {
+	return sort.Search(len(indices), func(i int) bool {
+		return s.Compare(indices[i], target) >= 0
+	})
+}
+
+// ToSortedSet returns a new Stringbank holding each distinct string in s once, saved in sorted order, along with
+// the indices of the strings in the new bank. As the strings are saved in order the indices are both sorted by
+// value and ascending, so they can be used directly with SearchPrefix
+func (s *Stringbank) ToSortedSet() (*Stringbank, []int) {
+	sorted := s.SortedIndices()
+	set := &Stringbank{chunk: s.chunk, nextChunk: s.nextChunk}
+	indices := make([]int, 0, len(sorted))
+	for i, index := range sorted {
+		val := s.Get(index)
+		if i > 0 && s.Get(sorted[i-1]) == val {
+			continue
+		}
+		indices = append(indices, set.Save(val))
+	}
+	return set, indices
+}
@@ -0,0 +1,88 @@
+package stringbank
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompare(t *testing.T) {
+	sb := Stringbank{}
+	i := sb.Save("banana")
+
+	assert.Equal(t, 0, sb.Compare(i, "banana"))
+	assert.Equal(t, 1, sb.Compare(i, "apple"))
+	assert.Equal(t, -1, sb.Compare(i, "cherry"))
+	assert.True(t, sb.HasPrefix(i, "ban"))
+	assert.True(t, sb.HasPrefix(i, ""))
+	assert.False(t, sb.HasPrefix(i, "nan"))
+}
+
+func TestSearchPrefix(t *testing.T) {
+	sb := Stringbank{}
+	for _, v := range []string{"pear", "apple", "banana", "cherry", "apricot", "blueberry"} {
+		sb.Save(v)
+	}
+
+	indices := sb.SortedIndices()
+	var sorted []string
+	for _, i := range indices {
+		sorted = append(sorted, sb.Get(i))
+	}
+	assert.Equal(t, []string{"apple", "apricot", "banana", "blueberry", "cherry", "pear"}, sorted)
+
+	tests := []struct {
+		target string
+		exp    int
+	}{
+		{"apple", 0},
+		{"ap", 0},
+		{"apr", 1},
+		{"b", 2},
+		{"blueberry", 3},
+		{"cherry", 4},
+		{"coconut", 5},
+		{"aardvark", 0},
+		{"zucchini", 6},
+	}
+	for _, test := range tests {
+		t.Run(test.target, func(t *testing.T) {
+			assert.Equal(t, test.exp, sb.SearchPrefix(indices, test.target))
+		})
+	}
+
+	pos := sb.SearchPrefix(indices, "ap")
+	assert.True(t, sb.HasPrefix(indices[pos], "ap"))
+	assert.True(t, sb.HasPrefix(indices[pos+1], "ap"))
+	assert.False(t, sb.HasPrefix(indices[pos+2], "ap"))
+}
+
+func TestToSortedSet(t *testing.T) {
+	sb := Stringbank{}
+	for _, v := range []string{"pear", "apple", "banana", "apple", "", "cherry", "pear", "banana", ""} {
+		sb.Save(v)
+	}
+
+	set, indices := sb.ToSortedSet()
+	var vals []string
+	for _, i := range indices {
+		vals = append(vals, set.Get(i))
+	}
+	assert.Equal(t, []string{"", "apple", "banana", "cherry", "pear"}, vals)
+	assert.Equal(t, 5, set.Len())
+	assert.Equal(t, 1, set.SearchPrefix(indices, "apple"))
+
+	set, indices = (&Stringbank{}).ToSortedSet()
+	assert.Equal(t, 0, set.Len())
+	assert.Empty(t, indices)
+}
+
+func TestToSortedSetChunkSize(t *testing.T) {
+	sb := New(64)
+	sb.SetChunkSize(1024)
+	sb.Save("b")
+	sb.Save("a")
+	set, _ := sb.ToSortedSet()
+	assert.Equal(t, 64, set.chunkSize())
+	assert.Equal(t, 1024, set.newChunkSize())
+}
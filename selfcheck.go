@@ -0,0 +1,43 @@
+package stringbank
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// SelfCheck checks the internal consistency of the bank. It walks every string, re-encodes its length and checks
+// the result matches the stored length prefix, and that the string fits within its chunk. It also checks the
+// number of strings and their total length agree with Len and DataBytes. It returns an error describing the first
+// problem found, or nil if there is none.
+func (s *Stringbank) SelfCheck() error {
+	chunkSize := s.chunkSize()
+	var count, dataBytes int
+	var prefix [binary.MaxVarintLen64]byte
+	for i, chunk := range s.allocations {
+		for offset := 0; offset < len(chunk); {
+			index := i*chunkSize + offset
+			ul, llen := binary.Uvarint(chunk[offset:])
+			l := int(ul)
+			if llen <= 0 || l < 0 {
+				return fmt.Errorf("stringbank: unreadable length prefix for string at index %d", index)
+			}
+			if n := writeLength(l, prefix[:]); !bytes.Equal(prefix[:n], chunk[offset:offset+llen]) {
+				return fmt.Errorf("stringbank: length prefix for string at index %d does not match its re-encoded length %d", index, l)
+			}
+			if l > len(chunk)-offset-llen {
+				return fmt.Errorf("stringbank: string at index %d of length %d runs past the end of its chunk", index, l)
+			}
+			count++
+			dataBytes += l
+			offset += llen + l
+		}
+	}
+	if count != s.count {
+		return fmt.Errorf("stringbank: found %d strings but Len is %d", count, s.count)
+	}
+	if dataBytes != s.dataBytes {
+		return fmt.Errorf("stringbank: strings total %d bytes but DataBytes is %d", dataBytes, s.dataBytes)
+	}
+	return nil
+}
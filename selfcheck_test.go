@@ -0,0 +1,63 @@
+package stringbank
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelfCheck(t *testing.T) {
+	build := func() (*Stringbank, []int) {
+		sb := New(1 << 10)
+		var indices []int
+		for i := 0; i < 1000; i++ {
+			v := strconv.Itoa(i)
+			if i%100 == 0 {
+				v = strings.Repeat(v, 100)
+			}
+			indices = append(indices, sb.Save(v))
+		}
+		indices = append(indices, sb.SaveCleanPath("a/b/../c"), sb.Save(""))
+		return sb, indices
+	}
+
+	sb, _ := build()
+	assert.NoError(t, sb.SelfCheck())
+	assert.NoError(t, (&Stringbank{}).SelfCheck())
+
+	t.Run("long length", func(t *testing.T) {
+		sb, indices := build()
+		// The last string is the empty string at the end of the last chunk
+		last := indices[len(indices)-1]
+		slot, offset := sb.locate(last)
+		sb.allocations[slot][offset] = 5
+		assert.EqualError(t, sb.SelfCheck(), "stringbank: string at index "+strconv.Itoa(last)+" of length 5 runs past the end of its chunk")
+	})
+
+	t.Run("padded prefix", func(t *testing.T) {
+		sb, indices := build()
+		// "1" is saved as a 1 byte prefix holding 1. 0x81 0x00 also decodes as 1, but isn't how we encode it
+		slot, offset := sb.locate(indices[1])
+		sb.allocations[slot][offset] = 0x81
+		sb.allocations[slot][offset+1] = 0x00
+		assert.EqualError(t, sb.SelfCheck(), "stringbank: length prefix for string at index "+strconv.Itoa(indices[1])+" does not match its re-encoded length 1")
+	})
+
+	t.Run("shorter length", func(t *testing.T) {
+		sb, indices := build()
+		slot, offset := sb.locate(indices[501])
+		// "501" becomes "50" followed by an empty string, so there's an extra string
+		sb.allocations[slot][offset] = 2
+		sb.allocations[slot][offset+3] = 0
+		assert.EqualError(t, sb.SelfCheck(), "stringbank: found 1003 strings but Len is 1002")
+	})
+
+	t.Run("unreadable", func(t *testing.T) {
+		sb, _ := build()
+		last := sb.allocations[len(sb.allocations)-1]
+		last[len(last)-1] = 0x80
+		assert.Error(t, sb.SelfCheck())
+	})
+}
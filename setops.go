@@ -0,0 +1,73 @@
+package stringbank
+
+import "bytes"
+
+// Union, Intersection and Difference treat Stringbanks as sets of strings. Each input must be a sorted set,
+// holding distinct strings saved in sorted order, as produced by ToSortedSet. The result is a new sorted set,
+// returned along with the indices of its strings in order.
+
+// Union returns a new sorted set holding the strings that are in either a or b
+func Union(a, b *Stringbank) (*Stringbank, []int) {
+	return mergeSets(a, b, true, true, true)
+}
+
+// Intersection returns a new sorted set holding the strings that are in both a and b
+func Intersection(a, b *Stringbank) (*Stringbank, []int) {
+	return mergeSets(a, b, false, true, false)
+}
+
+// Difference returns a new sorted set holding the strings that are in a but not in b
+func Difference(a, b *Stringbank) (*Stringbank, []int) {
+	return mergeSets(a, b, true, false, false)
+}
+
+// mergeSets walks a and b together in order. Strings only in a are kept if onlyA is set, strings in both if both
+// is set, and strings only in b if onlyB is set
+func mergeSets(a, b *Stringbank, onlyA, both, onlyB bool) (*Stringbank, []int) {
+	ai, bi := setIndices(a), setIndices(b)
+	result := &Stringbank{chunk: a.chunk, nextChunk: a.nextChunk}
+	var indices []int
+	keep := func(val []byte) {
+		indices = append(indices, result.SaveBytes(val))
+	}
+
+	var i, j int
+	for i < len(ai) && j < len(bi) {
+		aVal, bVal := a.GetBytes(ai[i]), b.GetBytes(bi[j])
+		switch bytes.Compare(aVal, bVal) {
+		case -1:
+			if onlyA {
+				keep(aVal)
+			}
+			i++
+		case 1:
+			if onlyB {
+				keep(bVal)
+			}
+			j++
+		default:
+			if both {
+				keep(aVal)
+			}
+			i++
+			j++
+		}
+	}
+	for ; onlyA && i < len(ai); i++ {
+		keep(a.GetBytes(ai[i]))
+	}
+	for ; onlyB && j < len(bi); j++ {
+		keep(b.GetBytes(bi[j]))
+	}
+	return result, indices
+}
+
+// setIndices returns the indices of the strings in s in the order they were saved
+func setIndices(s *Stringbank) []int {
+	indices := make([]int, 0, s.Len())
+	s.ForEachBytes(func(index int, b []byte) bool {
+		indices = append(indices, index)
+		return true
+	})
+	return indices
+}
@@ -0,0 +1,62 @@
+package stringbank
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetOps(t *testing.T) {
+	newSet := func(vals ...string) *Stringbank {
+		sb := Stringbank{}
+		for _, v := range vals {
+			sb.Save(v)
+		}
+		set, _ := sb.ToSortedSet()
+		return set
+	}
+	a := newSet("pear", "apple", "banana", "", "apple")
+	b := newSet("cherry", "banana", "zucchini", "", "date")
+	empty := newSet()
+
+	contents := func(sb *Stringbank, indices []int) []string {
+		vals := []string{}
+		for _, i := range indices {
+			vals = append(vals, sb.Get(i))
+		}
+		assert.Equal(t, len(vals), sb.Len())
+		return vals
+	}
+
+	tests := []struct {
+		name string
+		op   func(a, b *Stringbank) (*Stringbank, []int)
+		a, b *Stringbank
+		exp  []string
+	}{
+		{"union", Union, a, b, []string{"", "apple", "banana", "cherry", "date", "pear", "zucchini"}},
+		{"intersection", Intersection, a, b, []string{"", "banana"}},
+		{"difference", Difference, a, b, []string{"apple", "pear"}},
+		{"reverse difference", Difference, b, a, []string{"cherry", "date", "zucchini"}},
+		{"union with empty", Union, empty, b, []string{"", "banana", "cherry", "date", "zucchini"}},
+		{"intersection with empty", Intersection, a, empty, []string{}},
+		{"difference with empty", Difference, a, empty, []string{"", "apple", "banana", "pear"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.exp, contents(test.op(test.a, test.b)))
+		})
+	}
+}
+
+func TestSetOpsChunkSize(t *testing.T) {
+	a := New(64)
+	a.SetChunkSize(1024)
+	a.Save("a")
+	b := Stringbank{}
+	b.Save("b")
+	union, _ := Union(a, &b)
+	assert.Equal(t, 64, union.chunkSize())
+	assert.Equal(t, 1024, union.newChunkSize())
+}
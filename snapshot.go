@@ -0,0 +1,47 @@
+package stringbank
+
+// Snapshot holds a consistent set of statistics about a Stringbank, for monitoring
+type Snapshot struct {
+	// Len is the number of strings saved
+	Len int
+	// Size is the number of bytes allocated, as reported by Size
+	Size int
+	// Used is the number of bytes written, including length prefixes
+	Used int
+	// DataBytes is the total length of the strings saved
+	DataBytes int
+	// OverheadBytes is the number of bytes taken by length prefixes. It is Used less DataBytes
+	OverheadBytes int
+	// NumChunks is the number of allocations the bank has made, including those for large strings
+	NumChunks int
+	// Fragmentation is the fraction of the allocated bytes that are unused. It is zero for an empty bank
+	Fragmentation float64
+}
+
+// Snapshot returns the bank's statistics in a single call
+func (s *Stringbank) Snapshot() Snapshot {
+	snap := Snapshot{
+		Len:       s.Len(),
+		Size:      s.Size(),
+		Used:      s.Used(),
+		DataBytes: s.DataBytes(),
+	}
+	snap.OverheadBytes = snap.Used - snap.DataBytes
+	for _, data := range s.allocations {
+		if data != nil {
+			snap.NumChunks++
+		}
+	}
+	if snap.Size > 0 {
+		snap.Fragmentation = float64(snap.Size-snap.Used) / float64(snap.Size)
+	}
+	return snap
+}
+
+// Snapshot returns the bank's statistics in a single call. The lock is held throughout so the statistics are
+// consistent with one another
+func (s *SyncStringbank) Snapshot() Snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sb.Snapshot()
+}
@@ -0,0 +1,39 @@
+package stringbank
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshot(t *testing.T) {
+	sb := New(1 << 10)
+	assert.Equal(t, Snapshot{}, sb.Snapshot())
+
+	for i := 0; i < 1000; i++ {
+		sb.Save(strconv.Itoa(i))
+	}
+	sb.Save(strings.Repeat("a", 3000))
+
+	snap := sb.Snapshot()
+	assert.Equal(t, sb.Len(), snap.Len)
+	assert.Equal(t, sb.Size(), snap.Size)
+	assert.Equal(t, sb.Used(), snap.Used)
+	assert.Equal(t, sb.DataBytes(), snap.DataBytes)
+	assert.Equal(t, 1001+1, snap.OverheadBytes)
+	assert.Equal(t, 5, snap.NumChunks)
+	assert.InDelta(t, float64(sb.Size()-sb.Used())/float64(sb.Size()), snap.Fragmentation, 1e-9)
+	assert.True(t, snap.Fragmentation > 0)
+
+	var ssb SyncStringbank
+	for i := 0; i < 1000; i++ {
+		ssb.Save(strconv.Itoa(i))
+	}
+	ssb.Save(strings.Repeat("a", 3000))
+	ssnap := ssb.Snapshot()
+	assert.Equal(t, ssb.Len(), ssnap.Len)
+	assert.Equal(t, ssb.Size(), ssnap.Size)
+	assert.Equal(t, 1, ssnap.NumChunks)
+}
@@ -5,6 +5,10 @@ garbage collector. For small strings storage is reduced as the lengths are encod
 package stringbank
 
 import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
 	"math/bits"
 	"unsafe"
 )
@@ -25,58 +29,476 @@ func Save(val string) Index {
 	return Index(packageBank.Save(val))
 }
 
+// BankIndex is returned by SaveRef. Unlike Index it remembers which Stringbank the string was saved in, so its
+// String() method always resolves against the correct bank
+type BankIndex struct {
+	bank *Stringbank
+	idx  int
+}
+
+func (i BankIndex) String() string {
+	return i.bank.Get(i.idx)
+}
+
+// Index returns the index of the string within its Stringbank
+func (i BankIndex) Index() int {
+	return i.idx
+}
+
 // Stringbank is a place to put strings that never need to be deleted. Saving a string into the Stringbank
 // returns an integer offset for the string, so the string can be stored and referenced without bothering the
 // garbage collector. The offset can be exchanged for the original string via a call to Get
 type Stringbank struct {
-	current     []byte
+	// chunk is the size of each chunk. Zero means the default, stringbankSize. It is also the amount of index space
+	// taken up by each entry in allocations, so it never changes once strings have been saved
+	chunk int
+	// nextChunk is the size of chunks allocated from now on, if changed by SetChunkSize. Zero means chunk
+	nextChunk int
+	current   []byte
+	// allocations holds each chunk of the bank, sliced to the length that has been used. A string too large for a
+	// chunk is given an allocation of its own. This is followed by nil entries so that it takes up as many chunks'
+	// worth of index space as its size. That way each index still maps directly to a chunk and offset
 	allocations [][]byte
+	// starts holds, for each entry in allocations, the entry where the allocation covering it begins. It is nil
+	// until SetChunkSize allows chunks larger than chunk. Strings may then start beyond the first entry of their
+	// chunk, so we need this to find the chunk
+	starts []int
+	// size is the total capacity of the allocations
+	size int
+	// peak is the largest size has been
+	peak int
+	// pinned holds the indices of strings that must survive compaction
+	pinned map[int]struct{}
+	// dataBytes is the total length of the strings saved, excluding length prefixes
+	dataBytes int
+	// count is the number of strings saved
+	count int
+	// maxPrefixWidth is the most bytes used by any length prefix
+	maxPrefixWidth int
+	// ordinals holds the index of every ordinalStride'th string saved, so strings can be found by the order
+	// they were saved in
+	ordinals []int
+	// intern finds strings saved by SaveUnique
+	intern internTable
+	// keyed holds the sort keys of strings saved by SaveKeyed, in key order
+	keyed []keyedEntry
+	// byID holds index+1 of the string set for each id by SetByID, or zero if none has been set
+	byID []int
+	// longest maps the index of each key passed to SaveLongest to the index of its longest value
+	longest map[int]int
+	// log records each string saved, if set by SetLog
+	log    io.Writer
+	logErr error
+	// logBuf is space to build log records in without allocating
+	logBuf [1 + binary.MaxVarintLen64]byte
+	// frozen is set by Freeze to prevent further saves
+	frozen bool
+	// group tracks strings saved between BeginGroup and EndGroup
+	group group
+}
+
+// New returns a Stringbank that allocates memory in chunks of chunkSize bytes. Small chunks suit banks that hold
+// only a few strings, large chunks reduce the number of allocations for banks that hold many. chunkSize must be a
+// power of two, and New panics if it is not or if it is less than 16. A zero Stringbank uses chunks of 256KB.
+func New(chunkSize int) *Stringbank {
+	if err := checkChunkSize(chunkSize); err != nil {
+		panic(err)
+	}
+	return &Stringbank{chunk: chunkSize}
+}
+
+// minChunkSize is the smallest chunk size allowed. A chunk must hold at least a full length prefix
+const minChunkSize = 16
+
+// maxInt is the largest value of an int, used to check lengths read from files and logs
+const maxInt = int(^uint(0) >> 1)
+
+func checkChunkSize(chunkSize int) error {
+	if chunkSize < minChunkSize || chunkSize&(chunkSize-1) != 0 {
+		return fmt.Errorf("stringbank: chunk size %d is not a power of two of at least %d", chunkSize, minChunkSize)
+	}
+	return nil
+}
+
+// SetChunkSize changes the size of the chunks the bank allocates from now on. A bank can start with small chunks
+// and move to larger ones as it grows. Existing chunks and indices are unaffected, and strings continue to fill the
+// current chunk before a chunk of the new size is allocated. size must be a power of two, and SetChunkSize panics
+// if it is not or if it is less than 16.
+func (s *Stringbank) SetChunkSize(size int) {
+	if err := checkChunkSize(size); err != nil {
+		panic(err)
+	}
+	s.nextChunk = size
+}
+
+// newChunkSize returns the size to use for the next chunk allocated
+func (s *Stringbank) newChunkSize() int {
+	if s.nextChunk == 0 {
+		return s.chunkSize()
+	}
+	return s.nextChunk
+}
+
+// chunkSize returns the size of each chunk of the bank, unless changed by SetChunkSize. Each entry in allocations
+// covers this much index space
+func (s *Stringbank) chunkSize() int {
+	if s.chunk == 0 {
+		return stringbankSize
+	}
+	return s.chunk
 }
 
 // Size returns the approximate number of bytes in the string bank. The estimate includes currently unused and
 // wasted space
 func (s *Stringbank) Size() int {
-	return len(s.allocations) * stringbankSize
+	return s.size
+}
+
+// PeakSize returns the largest Size the bank has reached, including before any calls to Reset
+func (s *Stringbank) PeakSize() int {
+	return s.peak
+}
+
+// Reset discards all the strings in the bank so the bank can be reused. Indices from before the reset are no longer
+// valid. Strings previously returned by Get remain usable, but the memory behind them is freed only once they
+// are no longer referenced. The bank's chunk size and PeakSize are kept, but everything else, including any
+// interning, pins and save log, returns to its initial state.
+func (s *Stringbank) Reset() {
+	*s = Stringbank{
+		chunk:     s.chunk,
+		nextChunk: s.nextChunk,
+		peak:      s.peak,
+	}
+}
+
+// Used returns the number of bytes written to the bank, including length prefixes. Unlike Size it excludes the
+// unused space at the end of each chunk
+func (s *Stringbank) Used() int {
+	var used int
+	for _, data := range s.allocations {
+		used += len(data)
+	}
+	return used
+}
+
+// Len returns the number of strings saved in the bank
+func (s *Stringbank) Len() int {
+	return s.count
+}
+
+// GCCostAvoided estimates how many pointers the garbage collector would have to scan if the strings in the bank
+// were held as ordinary Go strings, for example in a []string. Each string header holds one pointer to the
+// string's data, so this is the same as Len()
+func (s *Stringbank) GCCostAvoided() int {
+	const pointersPerString = 1
+	return s.Len() * pointersPerString
+}
+
+// MaxPrefixWidth returns the largest number of bytes used to encode the length of any string in the bank. Each
+// byte of prefix holds 7 bits of the length, so strings shorter than 128 bytes have 1 byte prefixes.
+func (s *Stringbank) MaxPrefixWidth() int {
+	return s.maxPrefixWidth
+}
+
+// DataBytes returns the total length of the strings saved in the bank. Unlike Size this excludes length prefixes
+// and unused space
+func (s *Stringbank) DataBytes() int {
+	return s.dataBytes
+}
+
+// Efficiency returns the fraction of the memory allocated by the bank that holds string data. It is DataBytes()
+// divided by Size(), and is zero for an empty bank
+func (s *Stringbank) Efficiency() float64 {
+	size := s.Size()
+	if size == 0 {
+		return 0
+	}
+	return float64(s.DataBytes()) / float64(size)
+}
+
+// Owns returns true if index falls within the part of this Stringbank that holds strings. An index from another
+// bank usually fails this check, so it is useful for catching indices mixed up between banks. If the package is
+// built with the stringbankdebug tag, Get panics if it is passed an index that fails this check.
+func (s *Stringbank) Owns(index int) bool {
+	if index < 0 || index/s.chunkSize() >= len(s.allocations) {
+		return false
+	}
+	slot, offset := s.locate(index)
+	return offset < len(s.allocations[slot])
+}
+
+// locate returns the entry in allocations holding the string at index, and the offset of the string within it
+func (s *Stringbank) locate(index int) (slot, offset int) {
+	chunkSize := s.chunkSize()
+	slot, offset = index/chunkSize, index%chunkSize
+	if s.starts != nil {
+		start := s.starts[slot]
+		offset += (slot - start) * chunkSize
+		slot = start
+	}
+	return slot, offset
 }
 
 // Get converts an index to the original string
 func (s *Stringbank) Get(index int) string {
+	if debug && !s.Owns(index) {
+		panic("stringbank: index from wrong bank")
+	}
 	// read the length and string from the data
-	data := s.allocations[index/stringbankSize]
-	offset := index % stringbankSize
+	slot, offset := s.locate(index)
+	data := s.allocations[slot]
 	l, llen := readLength(data[offset:])
 
 	b := data[offset+llen : offset+llen+l]
 	return *(*string)(unsafe.Pointer(&b))
 }
 
+// GetBytes returns the string at index as a byte slice without copying it. The slice points into memory owned by
+// the Stringbank, so it must not be modified. Use GetBytesCopy if you need a slice you can change
+func (s *Stringbank) GetBytes(index int) []byte {
+	slot, offset := s.locate(index)
+	data := s.allocations[slot]
+	l, llen := readLength(data[offset:])
+
+	start := offset + llen
+	// Limit the capacity so appending to the slice can't overwrite the next string
+	return data[start : start+l : start+l]
+}
+
+// GetBytesCopy returns a copy of the string at index as a newly allocated byte slice, which the caller may modify
+// and keep
+func (s *Stringbank) GetBytesCopy(index int) []byte {
+	return append([]byte(nil), s.Get(index)...)
+}
+
+// ForEachBytes calls fn for each string in the Stringbank in the order they were saved, passing the index of the
+// string and its bytes. The byte slice points into memory owned by the Stringbank and must not be modified.
+// Iteration stops early if fn returns false
+func (s *Stringbank) ForEachBytes(fn func(index int, b []byte) bool) {
+	chunkSize := s.chunkSize()
+	for i, chunk := range s.allocations {
+		for offset := 0; offset < len(chunk); {
+			l, llen := readLength(chunk[offset:])
+			start := offset + llen
+			if !fn(i*chunkSize+offset, chunk[start:start+l]) {
+				return
+			}
+			offset = start + l
+		}
+	}
+}
+
+// ForEach calls fn for each string in the Stringbank in the order they were saved, passing the index of the
+// string and its value. Iteration stops early if fn returns false
+func (s *Stringbank) ForEach(fn func(index int, val string) bool) {
+	s.ForEachBytes(func(index int, b []byte) bool {
+		return fn(index, *(*string)(unsafe.Pointer(&b)))
+	})
+}
+
+// GetJoined returns the strings at the given indices joined by sep into a single newly allocated string
+func (s *Stringbank) GetJoined(sep string, indices ...int) string {
+	if len(indices) == 0 {
+		return ""
+	}
+
+	l := len(sep) * (len(indices) - 1)
+	for _, index := range indices {
+		l += len(s.Get(index))
+	}
+
+	b := make([]byte, 0, l)
+	for i, index := range indices {
+		if i > 0 {
+			b = append(b, sep...)
+		}
+		b = append(b, s.Get(index)...)
+	}
+	return *(*string)(unsafe.Pointer(&b))
+}
+
 // Save copies a string into the Stringbank, and returns the index of the string in the bank
 func (s *Stringbank) Save(tocopy string) int {
-	l := len(tocopy)
+	offset, buf := s.alloc(len(tocopy))
+	// Write the data
+	copy(buf, tocopy)
+	return s.logged(offset)
+}
+
+// SaveBytes copies a byte slice into the Stringbank, and returns the index of the data in the bank. It avoids the
+// allocation needed to convert the slice to a string to call Save. The data can be retrieved with Get or GetBytes
+func (s *Stringbank) SaveBytes(tocopy []byte) int {
+	offset, buf := s.alloc(len(tocopy))
+	copy(buf, tocopy)
+	return s.logged(offset)
+}
+
+// SaveAll saves each of vals, and returns their indices
+func (s *Stringbank) SaveAll(vals []string) []int {
+	return s.SaveAllInto(vals, make([]int, 0, len(vals)))
+}
+
+// SaveAllInto saves each of vals, and appends their indices to dst, returning the extended slice as append does.
+// If dst has enough spare capacity no allocation is made, so callers can reuse a buffer across calls
+func (s *Stringbank) SaveAllInto(vals []string, dst []int) []int {
+	for _, val := range vals {
+		dst = append(dst, s.Save(val))
+	}
+	return dst
+}
+
+// SaveRef copies a string into the Stringbank, and returns a BankIndex that can be converted back to the original
+// string by calling its String() method
+func (s *Stringbank) SaveRef(val string) BankIndex {
+	return BankIndex{bank: s, idx: s.Save(val)}
+}
+
+// SaveErr is like Save, but returns ErrFrozen rather than panicking if the Stringbank has been frozen
+func (s *Stringbank) SaveErr(tocopy string) (int, error) {
+	if s.frozen {
+		return 0, ErrFrozen
+	}
+	return s.Save(tocopy), nil
+}
+
+// ErrFrozen is returned by SaveErr if the Stringbank has been frozen
+var ErrFrozen = errors.New("stringbank: bank frozen")
+
+// Freeze prevents any further strings being saved in the Stringbank. Any subsequent attempt to save a string
+// panics, except via SaveErr, which returns ErrFrozen. Use this to catch accidental changes to a bank that has
+// been shared with readers.
+func (s *Stringbank) Freeze() {
+	s.frozen = true
+}
+
+// Frozen returns true if Freeze has been called
+func (s *Stringbank) Frozen() bool {
+	return s.frozen
+}
+
+// alloc reserves space for a new string of length l and writes its length. It returns the index of the new string
+// and the space for its data
+func (s *Stringbank) alloc(l int) (index int, data []byte) {
+	if s.frozen {
+		panic(ErrFrozen)
+	}
 	offset, buf := s.reserve(l + spaceForLength(l))
+	return offset, s.addString(l, offset, buf)
+}
+
+// addString records a new string of length l in the space buf reserved for it at index, and writes its length. It
+// returns the space for the string's data
+func (s *Stringbank) addString(l, index int, buf []byte) []byte {
+	if s.group.active {
+		s.noteGroup(index)
+	}
 	// Write the length
 	start := writeLength(l, buf)
+	if start > s.maxPrefixWidth {
+		s.maxPrefixWidth = start
+	}
 
-	// Write the data
-	copy(buf[start:], tocopy)
-	return offset
+	if s.count%ordinalStride == 0 {
+		s.ordinals = append(s.ordinals, index)
+	}
+	s.count++
+	s.dataBytes += l
+	return buf[start:]
 }
 
 // reserve finds a contiguous space of length l that can be used for writing data
 func (s *Stringbank) reserve(l int) (index int, data []byte) {
+	size := s.newChunkSize()
+	if l > size {
+		return s.reserveLarge(l)
+	}
 	if len(s.current)+l > cap(s.current) {
-		s.current = make([]byte, 0, stringbankSize)
-		s.allocations = append(s.allocations, s.current[0:stringbankSize])
+		s.startChunk(size)
+	}
+	return s.reserveCurrent(l)
+}
+
+// startChunk allocates a new chunk of size bytes and makes it the current chunk
+func (s *Stringbank) startChunk(size int) {
+	if size > s.chunkSize() && s.starts == nil {
+		s.buildStarts()
+	}
+	s.current = make([]byte, 0, size)
+	s.addAllocation(s.current, size)
+	s.grow(size)
+	s.logChunk(size)
+}
+
+// reserveCurrent reserves space of length l at the end of the current chunk, which must have room for it
+func (s *Stringbank) reserveCurrent(l int) (index int, data []byte) {
+	// The current chunk is the last allocation, though it may be followed by entries for the rest of its index
+	// space
+	slot := len(s.allocations) - 1
+	if s.starts != nil {
+		slot = s.starts[slot]
 	}
 	offset := len(s.current)
 	s.current = s.current[:offset+l]
-	return (len(s.allocations)-1)*stringbankSize + offset, s.current[offset:]
+	s.allocations[slot] = s.current
+	return slot*s.chunkSize() + offset, s.current[offset:]
+}
+
+// reserveLarge makes a dedicated allocation for data of length l, which is too large to fit in a chunk
+func (s *Stringbank) reserveLarge(l int) (index int, data []byte) {
+	data = make([]byte, l)
+	index = s.addAllocation(data, l) * s.chunkSize()
+	s.grow(l)
+	s.logChunk(l)
+	// Start a new chunk for the next string, so strings stay in the order they were saved
+	s.current = nil
+	return index, data
+}
+
+// grow records that n more bytes have been allocated
+func (s *Stringbank) grow(n int) {
+	s.size += n
+	if s.size > s.peak {
+		s.peak = s.size
+	}
+}
+
+// addAllocation adds data to allocations, followed by nil entries so that it takes up index space for size bytes.
+// It returns the entry holding data
+func (s *Stringbank) addAllocation(data []byte, size int) int {
+	chunkSize := s.chunkSize()
+	slot := len(s.allocations)
+	s.allocations = append(s.allocations, data)
+	for n := chunkSize; n < size; n += chunkSize {
+		s.allocations = append(s.allocations, nil)
+	}
+	if s.starts != nil {
+		for len(s.starts) < len(s.allocations) {
+			s.starts = append(s.starts, slot)
+		}
+	}
+	return slot
+}
+
+// buildStarts fills in starts from allocations. Each nil entry is covered by the allocation before it
+func (s *Stringbank) buildStarts() {
+	s.starts = make([]int, len(s.allocations))
+	var start int
+	for i, data := range s.allocations {
+		if data != nil {
+			start = i
+		}
+		s.starts[i] = start
+	}
 }
 
 func spaceForLength(len int) int {
 	// 7 bits => 1 byte
 	// 8 bits => 2 byte
 	// 1
+	// The empty string still needs a byte to record its length
+	if len == 0 {
+		return 1
+	}
 	bits := bits.Len(uint(len))
 	return (bits + 6) / 7
 }
@@ -85,16 +507,17 @@ func writeLength(len int, buf []byte) int {
 	// Want to write the length in a compact manner, with the assumption that short lengths
 	// are much more common
 	remainder := len
-	var i int
-	for i = 0; remainder != 0; i++ {
+	for i := 0; ; i++ {
 		val := byte(remainder & 0x7F)
 		remainder = remainder >> 7
 		if remainder != 0 {
 			val |= 0x80
 		}
 		buf[i] = val
+		if remainder == 0 {
+			return i + 1
+		}
 	}
-	return i
 }
 
 func readLength(buf []byte) (int, int) {
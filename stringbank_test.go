@@ -1,13 +1,16 @@
 package stringbank
 
 import (
+	"bytes"
 	"fmt"
 	"runtime"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestStringbank(t *testing.T) {
@@ -22,6 +25,411 @@ func TestStringbank(t *testing.T) {
 	assert.Equal(t, "cheese", sb.Get(s3))
 }
 
+func TestOwns(t *testing.T) {
+	a := Stringbank{}
+	b := Stringbank{}
+	b.Save("hello")
+
+	var indices []int
+	for i := 0; i < 100000; i++ {
+		indices = append(indices, a.Save(strconv.Itoa(i)))
+	}
+
+	assert.True(t, a.Owns(indices[0]))
+	assert.True(t, a.Owns(indices[len(indices)-1]))
+	assert.True(t, b.Owns(indices[0]))
+	assert.False(t, b.Owns(indices[10]))
+	assert.False(t, b.Owns(indices[len(indices)-1]))
+	assert.False(t, a.Owns(-1))
+	assert.False(t, (&Stringbank{}).Owns(0))
+
+	if debug {
+		assert.PanicsWithValue(t, "stringbank: index from wrong bank", func() { b.Get(indices[10]) })
+	}
+}
+
+func TestEmptyString(t *testing.T) {
+	sb := Stringbank{}
+
+	s1 := sb.Save("")
+	s2 := sb.Save("hello")
+
+	assert.Equal(t, "", sb.Get(s1))
+	assert.Equal(t, "hello", sb.Get(s2))
+}
+
+func TestSaveBytes(t *testing.T) {
+	sb := Stringbank{}
+
+	b := []byte("hello")
+	s1 := sb.SaveBytes(b)
+	s2 := sb.SaveBytes(nil)
+	s3 := sb.Save("goodbye")
+	b[0] = 'j'
+
+	assert.Equal(t, "hello", sb.Get(s1))
+	assert.Equal(t, "", sb.Get(s2))
+	assert.Equal(t, []byte("hello"), sb.GetBytes(s1))
+	assert.Equal(t, []byte{}, sb.GetBytes(s2))
+	assert.Equal(t, []byte("goodbye"), sb.GetBytes(s3))
+
+	// Appending to the slice must not overwrite the next string
+	_ = append(sb.GetBytes(s1), "xxxx"...)
+	assert.Equal(t, "goodbye", sb.Get(s3))
+}
+
+func TestGetBytesCopy(t *testing.T) {
+	sb := Stringbank{}
+	s1 := sb.Save("hello")
+	s2 := sb.Save("goodbye")
+
+	b := sb.GetBytesCopy(s1)
+	assert.Equal(t, []byte("hello"), b)
+	b[0] = 'j'
+	b = append(b, "goodbye"...)
+	assert.Equal(t, "hello", sb.Get(s1))
+	assert.Equal(t, "goodbye", sb.Get(s2))
+	assert.Equal(t, "jellogoodbye", string(b))
+}
+
+func TestForEachBytes(t *testing.T) {
+	sb := Stringbank{}
+	var indices []int
+	var values []string
+	for i := 0; i < 100000; i++ {
+		v := strconv.Itoa(i)
+		indices = append(indices, sb.Save(v))
+		values = append(values, v)
+	}
+
+	var i int
+	sb.ForEachBytes(func(index int, b []byte) bool {
+		assert.Equal(t, indices[i], index)
+		assert.Equal(t, values[i], string(b))
+		i++
+		return true
+	})
+	assert.Equal(t, len(indices), i)
+
+	i = 0
+	sb.ForEachBytes(func(index int, b []byte) bool {
+		i++
+		return i < 3
+	})
+	assert.Equal(t, 3, i)
+}
+
+func TestSetChunkSize(t *testing.T) {
+	sb := New(64)
+	var indices []int
+	var vals []string
+	save := func(n int) {
+		for i := 0; i < n; i++ {
+			v := strconv.Itoa(len(vals))
+			if i%50 == 0 {
+				v = strings.Repeat(v, 100)
+			}
+			indices = append(indices, sb.Save(v))
+			vals = append(vals, v)
+		}
+	}
+	save(100)
+	sb.SetChunkSize(1 << 12)
+	save(1000)
+	sb.SetChunkSize(32)
+	save(100)
+	sb.SetChunkSize(1 << 10)
+	save(1000)
+
+	check := func(t *testing.T, sb *Stringbank) {
+		for i, index := range indices {
+			assert.True(t, sb.Owns(index))
+			assert.Equal(t, vals[i], sb.Get(index))
+			assert.Equal(t, vals[i], string(sb.GetBytes(index)))
+		}
+		var i int
+		sb.ForEach(func(index int, val string) bool {
+			assert.Equal(t, indices[i], index)
+			assert.Equal(t, vals[i], val)
+			i++
+			return true
+		})
+		assert.Equal(t, len(vals), i)
+		for i := range vals {
+			val, index := sb.GetOrdinal(i)
+			assert.Equal(t, vals[i], val)
+			assert.Equal(t, indices[i], index)
+		}
+	}
+	check(t, sb)
+
+	// Strings saved in one large chunk share it
+	assert.True(t, sb.SameChunk(indices[101], indices[149]))
+	assert.False(t, sb.Owns(indices[len(indices)-1]+1<<10))
+
+	var buf bytes.Buffer
+	_, err := sb.WriteTo(&buf)
+	require.NoError(t, err)
+	loaded, err := ReadFrom(&buf)
+	require.NoError(t, err)
+	check(t, loaded)
+
+	assert.Panics(t, func() { sb.SetChunkSize(1000) })
+}
+
+func TestSaveAll(t *testing.T) {
+	sb := Stringbank{}
+	vals := []string{"a", "", "bc", strings.Repeat("d", 300)}
+	indices := sb.SaveAll(vals)
+	assert.Len(t, indices, len(vals))
+	for i, index := range indices {
+		assert.Equal(t, vals[i], sb.Get(index))
+	}
+	assert.Empty(t, sb.SaveAll(nil))
+}
+
+func TestSaveAllInto(t *testing.T) {
+	sb := Stringbank{}
+	vals := []string{"a", "", "bc", strings.Repeat("d", 300)}
+
+	buf := make([]int, 1, 10)
+	buf[0] = -1
+	indices := sb.SaveAllInto(vals, buf)
+	assert.Len(t, indices, 5)
+	assert.Equal(t, -1, indices[0])
+	// The indices are written into buf's backing array
+	assert.True(t, &buf[0] == &indices[0])
+	for i, index := range indices[1:] {
+		assert.Equal(t, vals[i], sb.Get(index))
+	}
+
+	// Reusing the buffer doesn't allocate
+	allocs := testing.AllocsPerRun(100, func() {
+		indices = sb.SaveAllInto(vals[:3], buf[:0])
+	})
+	assert.Zero(t, allocs)
+	assert.Equal(t, "bc", sb.Get(indices[2]))
+
+	// A buffer that's too small is grown
+	indices = sb.SaveAllInto(vals, buf[:0:2])
+	assert.Len(t, indices, 4)
+	assert.True(t, &buf[0] != &indices[0])
+}
+
+func TestPeakSize(t *testing.T) {
+	sb := New(64)
+	assert.Zero(t, sb.PeakSize())
+	for i := 0; i < 100; i++ {
+		sb.Save("hello")
+	}
+	sb.Save(strings.Repeat("a", 100))
+	peak := sb.Size()
+	assert.Equal(t, 10*64+101, peak)
+	assert.Equal(t, peak, sb.PeakSize())
+
+	sb.Reset()
+	assert.Zero(t, sb.Size())
+	assert.Zero(t, sb.Len())
+	assert.Zero(t, sb.Used())
+	assert.Equal(t, peak, sb.PeakSize())
+
+	// The bank is usable after a reset, and keeps its chunk size
+	index := sb.Save("hello")
+	assert.Equal(t, 0, index)
+	assert.Equal(t, "hello", sb.Get(index))
+	assert.Equal(t, 64, sb.Size())
+	assert.Equal(t, peak, sb.PeakSize())
+
+	for i := 0; i < 200; i++ {
+		sb.Save("hello")
+	}
+	assert.Equal(t, sb.Size(), sb.PeakSize())
+	assert.True(t, sb.PeakSize() > peak)
+}
+
+func TestPeakSizeReadFrom(t *testing.T) {
+	sb := Stringbank{}
+	sb.Save("hello")
+	var buf bytes.Buffer
+	_, err := sb.WriteTo(&buf)
+	require.NoError(t, err)
+	loaded, err := ReadFrom(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, loaded.Size(), loaded.PeakSize())
+}
+
+func TestUsed(t *testing.T) {
+	sb := New(64)
+	assert.Zero(t, sb.Used())
+	assert.Zero(t, sb.Len())
+
+	sb.Save("hello")
+	sb.Save("")
+	assert.Equal(t, 7, sb.Used())
+	assert.Equal(t, 64, sb.Size())
+
+	// This crosses into a new chunk, leaving the end of the first unused
+	sb.Save(strings.Repeat("a", 60))
+	assert.Equal(t, 68, sb.Used())
+	assert.Equal(t, 128, sb.Size())
+
+	sb.Save(strings.Repeat("a", 100))
+	assert.Equal(t, 169, sb.Used())
+	assert.Equal(t, 4, sb.Len())
+}
+
+func TestForEach(t *testing.T) {
+	sb := New(1 << 12)
+	var indices []int
+	var vals []string
+	for i := 0; i < 10000; i++ {
+		v := strconv.Itoa(i)
+		switch i % 1000 {
+		case 0:
+			v = strings.Repeat(v, 1000)
+		case 1:
+			v = ""
+		case 2:
+			v = strings.Repeat(v, 100)
+		}
+		indices = append(indices, sb.Save(v))
+		vals = append(vals, v)
+	}
+
+	var i int
+	sb.ForEach(func(index int, val string) bool {
+		assert.Equal(t, indices[i], index)
+		assert.Equal(t, vals[i], val)
+		i++
+		return true
+	})
+	assert.Equal(t, len(indices), i)
+
+	i = 0
+	sb.ForEach(func(index int, val string) bool {
+		i++
+		return false
+	})
+	assert.Equal(t, 1, i)
+
+	(&Stringbank{}).ForEach(func(index int, val string) bool {
+		t.Fatal("empty bank should have no strings")
+		return true
+	})
+}
+
+func TestGetJoined(t *testing.T) {
+	sb := Stringbank{}
+
+	s1 := sb.Save("hello")
+	s2 := sb.Save("")
+	s3 := sb.Save("cheese")
+
+	assert.Equal(t, "", sb.GetJoined(", "))
+	assert.Equal(t, "hello", sb.GetJoined(", ", s1))
+	assert.Equal(t, strings.Join([]string{"hello", "", "cheese", "hello"}, ", "), sb.GetJoined(", ", s1, s2, s3, s1))
+	assert.Equal(t, "hellocheese", sb.GetJoined("", s1, s3))
+}
+
+func TestFreeze(t *testing.T) {
+	sb := Stringbank{}
+	s1 := sb.Save("hello")
+	s2, err := sb.SaveErr("goodbye")
+	assert.NoError(t, err)
+	assert.False(t, sb.Frozen())
+
+	sb.Freeze()
+	assert.True(t, sb.Frozen())
+
+	assert.PanicsWithValue(t, ErrFrozen, func() { sb.Save("cheese") })
+	assert.PanicsWithValue(t, ErrFrozen, func() { sb.SaveUnique("cheese") })
+	_, err = sb.SaveErr("cheese")
+	assert.Equal(t, ErrFrozen, err)
+
+	assert.Equal(t, "hello", sb.Get(s1))
+	assert.Equal(t, "goodbye", sb.Get(s2))
+	assert.Equal(t, 2, sb.Len())
+}
+
+func TestLargeStrings(t *testing.T) {
+	// The length prefix for these strings takes 3 bytes
+	exact := strings.Repeat("e", stringbankSize-3)
+	over := strings.Repeat("o", stringbankSize-2)
+	huge := strings.Repeat("h", 3*stringbankSize+17)
+
+	sb := Stringbank{}
+	vals := []string{"hello", exact, "a", over, "b", huge, "c", huge, over, exact, exact, "d"}
+	var indices []int
+	for _, v := range vals {
+		indices = append(indices, sb.Save(v))
+	}
+
+	for i, index := range indices {
+		assert.Equal(t, vals[i], sb.Get(index))
+	}
+
+	var i int
+	sb.ForEachBytes(func(index int, b []byte) bool {
+		assert.Equal(t, indices[i], index)
+		assert.Equal(t, vals[i], string(b))
+		i++
+		return true
+	})
+	assert.Equal(t, len(vals), i)
+
+	for ordinal, index := range indices {
+		_, ordIndex := sb.GetOrdinal(ordinal)
+		assert.Equal(t, index, ordIndex)
+	}
+
+	// The exact size string fills a chunk by itself
+	assert.Equal(t, stringbankSize, len(sb.allocations[indices[1]/stringbankSize]))
+	assert.Equal(t, 0, indices[2]%stringbankSize)
+}
+
+func TestLargeStringSize(t *testing.T) {
+	sb := Stringbank{}
+	sb.Save("hello")
+	sb.Save(strings.Repeat("o", stringbankSize))
+	assert.Equal(t, 2*stringbankSize+3, sb.Size())
+	sb.Save("hello")
+	assert.Equal(t, 3*stringbankSize+3, sb.Size())
+}
+
+func TestNew(t *testing.T) {
+	small := New(16)
+	small.Save("hello")
+	assert.Equal(t, 16, small.Size())
+
+	vals := []string{"hello", "a string longer than a chunk", "", "goodbye", strings.Repeat("a", 1<<20)}
+	for _, sb := range []*Stringbank{small, New(1 << 10), New(1 << 24)} {
+		base := sb.Len()
+		var indices []int
+		for _, v := range vals {
+			indices = append(indices, sb.Save(v))
+		}
+		for i, index := range indices {
+			assert.Equal(t, vals[i], sb.Get(index))
+			_, ordIndex := sb.GetOrdinal(base + i)
+			assert.Equal(t, index, ordIndex)
+		}
+
+		var buf bytes.Buffer
+		_, err := sb.WriteTo(&buf)
+		require.NoError(t, err)
+		loaded, err := ReadFrom(&buf)
+		require.NoError(t, err)
+		for i, index := range indices {
+			assert.Equal(t, vals[i], loaded.Get(index))
+		}
+	}
+
+	assert.Panics(t, func() { New(0) })
+	assert.Panics(t, func() { New(-16) })
+	assert.Panics(t, func() { New(8) })
+	assert.Panics(t, func() { New(1000) })
+}
+
 func TestStringbankSize(t *testing.T) {
 	sb := Stringbank{}
 	assert.Zero(t, sb.Size())
@@ -29,6 +437,56 @@ func TestStringbankSize(t *testing.T) {
 	assert.Equal(t, stringbankSize, sb.Size())
 }
 
+func TestLen(t *testing.T) {
+	sb := Stringbank{}
+	assert.Zero(t, sb.Len())
+	assert.Zero(t, sb.GCCostAvoided())
+
+	for i := 0; i < 100000; i++ {
+		sb.Save(strconv.Itoa(i))
+	}
+	sb.SaveUnique("hello")
+	sb.SaveUnique("hello")
+	assert.Equal(t, 100001, sb.Len())
+	assert.Equal(t, sb.Len(), sb.GCCostAvoided())
+
+	sb.Save("")
+	assert.Equal(t, 100002, sb.GCCostAvoided())
+}
+
+func TestMaxPrefixWidth(t *testing.T) {
+	sb := Stringbank{}
+	assert.Zero(t, sb.MaxPrefixWidth())
+
+	sb.Save("")
+	assert.Equal(t, 1, sb.MaxPrefixWidth())
+	sb.Save(strings.Repeat("a", 127))
+	assert.Equal(t, 1, sb.MaxPrefixWidth())
+	sb.Save(strings.Repeat("a", 128))
+	assert.Equal(t, 2, sb.MaxPrefixWidth())
+	sb.Save("hello")
+	assert.Equal(t, 2, sb.MaxPrefixWidth())
+	sb.Save(strings.Repeat("a", 1<<14))
+	assert.Equal(t, 3, sb.MaxPrefixWidth())
+	sb.Save(strings.Repeat("a", 1<<14-1))
+	assert.Equal(t, 3, sb.MaxPrefixWidth())
+}
+
+func TestEfficiency(t *testing.T) {
+	sb := Stringbank{}
+	assert.Zero(t, sb.Efficiency())
+
+	sb.Save("hello")
+	sb.Save(strings.Repeat("a", 200))
+	assert.Equal(t, 205, sb.DataBytes())
+	assert.Equal(t, 205.0/stringbankSize, sb.Efficiency())
+
+	for sb.Size() == stringbankSize {
+		sb.Save(strings.Repeat("a", 1000))
+	}
+	assert.Equal(t, float64(sb.DataBytes())/(2*stringbankSize), sb.Efficiency())
+}
+
 func TestPackageBank(t *testing.T) {
 	s1 := Save("hello")
 	s2 := Save("goodbye")
@@ -39,10 +497,30 @@ func TestPackageBank(t *testing.T) {
 	assert.Equal(t, "cheese", s3.String())
 }
 
+func TestSaveRef(t *testing.T) {
+	sb := Stringbank{}
+
+	r1 := sb.SaveRef("hello")
+	r2 := sb.SaveRef("goodbye")
+	g1 := Save("cheese")
+
+	assert.Equal(t, "hello", r1.String())
+	assert.Equal(t, "goodbye", r2.String())
+	assert.Equal(t, "goodbye", sb.Get(r2.Index()))
+	assert.Equal(t, "cheese", g1.String())
+
+	other := Stringbank{}
+	o1 := other.SaveRef("cheese")
+	assert.Equal(t, r1.Index(), o1.Index())
+	assert.Equal(t, "hello", r1.String())
+	assert.Equal(t, "cheese", o1.String())
+}
+
 func TestLengths(t *testing.T) {
 	tests := []struct {
 		len int
 	}{
+		{0},
 		{1},
 		{127},
 		{128},
@@ -102,6 +580,26 @@ func BenchmarkStringbank(b *testing.B) {
 	}
 }
 
+func BenchmarkSaveStringConversion(b *testing.B) {
+	// Longer than 32 bytes, so the conversion to a string can't use a buffer on the stack
+	data := []byte("a typical string that is a little over 32 bytes long")
+	b.ReportAllocs()
+	sb := Stringbank{}
+	for i := 0; i < b.N; i++ {
+		sb.Save(string(data))
+	}
+}
+
+func BenchmarkSaveBytes(b *testing.B) {
+	// Longer than 32 bytes, so the conversion to a string can't use a buffer on the stack
+	data := []byte("a typical string that is a little over 32 bytes long")
+	b.ReportAllocs()
+	sb := Stringbank{}
+	for i := 0; i < b.N; i++ {
+		sb.SaveBytes(data)
+	}
+}
+
 func ExampleSave() {
 	i := Save("hello")
 	fmt.Println(i)
@@ -0,0 +1,59 @@
+package stringbank
+
+import (
+	"sync"
+	"unsafe"
+)
+
+// SyncStringbank is a Stringbank that is safe for concurrent use. Save only holds a lock while it reserves space
+// for the string, and copies the string's data after releasing the lock, so concurrent saves of large strings
+// proceed in parallel. Get may be called concurrently with Save.
+type SyncStringbank struct {
+	mu sync.RWMutex
+	sb Stringbank
+}
+
+// Save copies a string into the SyncStringbank, and returns the index of the string in the bank
+func (s *SyncStringbank) Save(tocopy string) int {
+	s.mu.Lock()
+	index, buf := s.sb.alloc(len(tocopy))
+	s.mu.Unlock()
+	// Nothing else touches this space until we return its index, so we don't need the lock to fill it in
+	copy(buf, tocopy)
+	return index
+}
+
+// SaveUnique is like Stringbank.SaveUnique. Unlike Save it holds the lock while copying the string
+func (s *SyncStringbank) SaveUnique(val string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sb.SaveUnique(val)
+}
+
+// Get converts an index to the original string
+func (s *SyncStringbank) Get(index int) string {
+	// Saves may append to allocations, so we need the lock to read it
+	s.mu.RLock()
+	slot, offset := s.sb.locate(index)
+	data := s.sb.allocations[slot]
+	s.mu.RUnlock()
+
+	l, llen := readLength(data[offset:])
+	b := data[offset+llen : offset+llen+l]
+	return *(*string)(unsafe.Pointer(&b))
+}
+
+// Len returns the number of strings saved in the bank
+func (s *SyncStringbank) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sb.Len()
+}
+
+// Size returns the approximate number of bytes in the string bank. The estimate includes currently unused and
+// wasted space
+func (s *SyncStringbank) Size() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sb.Size()
+}
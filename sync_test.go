@@ -0,0 +1,50 @@
+package stringbank
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncStringbank(t *testing.T) {
+	const (
+		goroutines = 8
+		saves      = 20000
+	)
+
+	var sb SyncStringbank
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			prefix := strconv.Itoa(g) + ":"
+			indices := make([]int, saves)
+			for i := range indices {
+				val := prefix + strconv.Itoa(i)
+				if i%5000 == 0 {
+					// Occasionally save something too big for a chunk
+					val += strings.Repeat("x", stringbankSize)
+				}
+				indices[i] = sb.Save(val)
+				sb.SaveUnique(strconv.Itoa(i % 100))
+				if got := sb.Get(indices[i]); got != val {
+					t.Errorf("got %.20q, expected %.20q", got, val)
+				}
+			}
+			for i, index := range indices {
+				val := prefix + strconv.Itoa(i)
+				if got := sb.Get(index); !strings.HasPrefix(got, val) {
+					t.Errorf("got %.20q, expected %.20q", got, val)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	assert.Equal(t, goroutines*saves+100, sb.Len())
+	assert.True(t, sb.Size() > 0)
+}
@@ -0,0 +1,48 @@
+package stringbank
+
+import (
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// SaveUTF16 saves UTF-16 encoded text in the Stringbank as UTF-8, and returns its index. The text is transcoded
+// directly into the bank, without building an intermediate string, and Get returns the UTF-8 string as normal.
+// Surrogate pairs are combined into a single character, and unpaired surrogates are replaced by U+FFFD, as with
+// utf16.Decode
+func (s *Stringbank) SaveUTF16(u []uint16) int {
+	// Find the length of the UTF-8 encoding first so we can reserve exactly the space needed
+	var l int
+	for i := 0; i < len(u); i++ {
+		r, pair := decodeUTF16(u, i)
+		if pair {
+			i++
+		}
+		l += utf8.RuneLen(r)
+	}
+
+	offset, buf := s.alloc(l)
+	var n int
+	for i := 0; i < len(u); i++ {
+		r, pair := decodeUTF16(u, i)
+		if pair {
+			i++
+		}
+		n += utf8.EncodeRune(buf[n:], r)
+	}
+	return s.logged(offset)
+}
+
+// decodeUTF16 decodes the character at u[i]. It returns true if the character is a surrogate pair, so takes up
+// u[i+1] as well
+func decodeUTF16(u []uint16, i int) (rune, bool) {
+	r := rune(u[i])
+	if !utf16.IsSurrogate(r) {
+		return r, false
+	}
+	if i+1 < len(u) {
+		if dec := utf16.DecodeRune(r, rune(u[i+1])); dec != utf8.RuneError {
+			return dec, true
+		}
+	}
+	return utf8.RuneError, false
+}
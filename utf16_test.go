@@ -0,0 +1,40 @@
+package stringbank
+
+import (
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveUTF16(t *testing.T) {
+	sb := Stringbank{}
+	tests := []string{
+		"",
+		"hello",
+		"héllo wörld",
+		"日本語のテキスト",
+		"astral 😀 plane 𝄞 characters 🇬🇧",
+	}
+
+	var indices []int
+	for _, test := range tests {
+		indices = append(indices, sb.SaveUTF16(utf16.Encode([]rune(test))))
+	}
+	for i, test := range tests {
+		assert.Equal(t, test, sb.Get(indices[i]))
+	}
+}
+
+func TestSaveUTF16BadSurrogates(t *testing.T) {
+	sb := Stringbank{}
+	tests := [][]uint16{
+		{0xD83D},
+		{'a', 0xD83D, 'b'},
+		{0xDE00, 0xD83D},
+		{0xD83D, 0xD83D, 0xDE00},
+	}
+	for _, test := range tests {
+		assert.Equal(t, string(utf16.Decode(test)), sb.Get(sb.SaveUTF16(test)))
+	}
+}